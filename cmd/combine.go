@@ -0,0 +1,63 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto"
+	"log"
+	"path/filepath"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/slice"
+	"github.com/spf13/cobra"
+)
+
+var combineKeyFlags = struct {
+	targetDirectory string
+	overwrite       bool
+}{}
+
+// combineCmd represents the combine command
+var combineCmd = &cobra.Command{
+	Use:   "combine [share-file...]",
+	Short: "Rebuild a private/public keypair from key shares",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		shares, err := slice.MapWithErr(args, fs.ReadFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		private, err := key.CombinePrivateKey(shares)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		privateBytes, publicBytes, err := key.MarshalKeyPair(private, private.(crypto.Signer).Public())
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = fs.SaveCreateIntermediate(
+			filepath.Join(combineKeyFlags.targetDirectory, constant.PRIVATE_KEY_FILE_NAME), privateBytes, combineKeyFlags.overwrite)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = fs.SaveCreateIntermediate(
+			filepath.Join(combineKeyFlags.targetDirectory, constant.PUBLIC_KEY_FILE_NAME), publicBytes, combineKeyFlags.overwrite)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	keyCmd.AddCommand(combineCmd)
+
+	combineCmd.Flags().StringVarP(&combineKeyFlags.targetDirectory, "target-directory", "d", ".", "Directory used to save the rebuilt key pair")
+	combineCmd.Flags().BoolVarP(&combineKeyFlags.overwrite, "overwrite", "o", false, "Overwrite existing key files")
+}
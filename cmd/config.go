@@ -0,0 +1,44 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration providing flag defaults",
+	Long: `Inspect the configuration providing flag defaults.
+
+Flags that are not given on the command line are taken from, in order:
+
+  1. FILE_SIGNER_<FLAG> environment variables, e.g. FILE_SIGNER_PRIVATE_KEY
+  2. the context selected by --context, FILE_SIGNER_CONTEXT or "context"
+  3. the section of the running command under "commands"
+  4. "defaults"
+
+Each level is looked up in the project-local .file-signer.yaml (found in the
+working directory or its parents) before $XDG_CONFIG_HOME/file-signer/config.yaml.
+Settings are keyed by flag name:
+
+  context: production
+  defaults:
+    target-directory: licences
+  commands:
+    licence sign:
+      format: pem
+  contexts:
+    production:
+      issuer: licensing@example.com
+      private-key: /secure/production.key
+      format: jws
+    staging:
+      private-key: keys/staging.key`,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eslam-allam/file-signer/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/thediveo/enumflag/v2"
+)
+
+var configShowCmdFlags = struct {
+	output outputFormat
+}{}
+
+type configSetting struct {
+	Command string   `json:"command,omitempty"`
+	Flag    string   `json:"flag"`
+	Value   []string `json:"value"`
+	Source  string   `json:"source"`
+}
+
+type configReport struct {
+	Files         []string        `json:"files"`
+	Context       string          `json:"context,omitempty"`
+	ContextSource string          `json:"context_source,omitempty"`
+	Settings      []configSetting `json:"settings"`
+}
+
+// configShowCmd represents the config show command
+var configShowCmd = &cobra.Command{
+	Use:   "show [command...]",
+	Short: "Print the effective settings and their sources",
+	Long: `Print the effective settings and their sources.
+
+Without arguments every configured setting is shown. Given a command, such as
+"licence sign", all of its flags are shown with the value they would take.`,
+	Example: `  file-signer config show
+  file-signer config show licence sign --context staging`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(rootCmdFlags.context)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		report := configReport{Files: []string{}, Context: c.Context, ContextSource: c.ContextSource}
+		for _, f := range c.Files {
+			report.Files = append(report.Files, f.Path)
+		}
+
+		if len(args) != 0 {
+			target, rest, err := rootCmd.Find(args)
+			if err != nil || len(rest) != 0 {
+				log.Fatalf("unknown command '%s'", strings.Join(args, " "))
+			}
+			report.Settings = commandSettings(target, c)
+		} else {
+			report.Settings = configuredSettings(c)
+		}
+
+		if configShowCmdFlags.output == OUTPUT_JSON {
+			reportBytes, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(string(reportBytes))
+			return
+		}
+		printConfigReport(report)
+	},
+}
+
+// configuredSettings resolves every flag named by the environment, the
+// defaults, the selected context and the command sections.
+func configuredSettings(c *config.Config) []configSetting {
+	global, commands := c.Flags()
+	settings := []configSetting{}
+	for _, flag := range global {
+		setting, _ := c.Lookup("", flag)
+		settings = append(settings, configSetting{Flag: flag, Value: setting.Value, Source: setting.Source})
+	}
+	for _, command := range sortedCommands(commands) {
+		for _, flag := range commands[command] {
+			setting, _ := c.Lookup(command, flag)
+			settings = append(settings, configSetting{Command: command, Flag: flag, Value: setting.Value, Source: setting.Source})
+		}
+	}
+	return settings
+}
+
+// commandSettings resolves every flag of target, falling back to the flag
+// defaults.
+func commandSettings(target *cobra.Command, c *config.Config) []configSetting {
+	command := commandName(target)
+	flags := pflag.NewFlagSet(command, pflag.ContinueOnError)
+	flags.AddFlagSet(target.LocalFlags())
+	flags.AddFlagSet(target.InheritedFlags())
+
+	settings := []configSetting{}
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Name == "context" || f.Name == "help" {
+			return
+		}
+		setting, ok := c.Lookup(command, f.Name)
+		if !ok {
+			setting = config.Setting{Value: config.Value{f.DefValue}, Source: "default"}
+		}
+		settings = append(settings, configSetting{Command: command, Flag: f.Name, Value: setting.Value, Source: setting.Source})
+	})
+	return settings
+}
+
+func sortedCommands(commands map[string][]string) []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func printConfigReport(report configReport) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	files := "none"
+	if len(report.Files) != 0 {
+		files = strings.Join(report.Files, ", ")
+	}
+	fmt.Fprintf(tw, "Configuration files:\t%s\n", files)
+	if report.Context != "" {
+		fmt.Fprintf(tw, "Context:\t%s (from %s)\n", report.Context, report.ContextSource)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "COMMAND\tFLAG\tVALUE\tSOURCE")
+	for _, s := range report.Settings {
+		command := s.Command
+		if command == "" {
+			command = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", command, s.Flag, strings.Join(s.Value, ", "), s.Source)
+	}
+	tw.Flush()
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+
+	oe := enumflag.New(
+		&configShowCmdFlags.output,
+		"output",
+		outputFormats,
+		enumflag.EnumCaseInsensitive,
+	)
+	oe.RegisterCompletion(configShowCmd, "output", outputFormatDescription)
+	configShowCmd.Flags().Var(oe, "output", "Format of the settings")
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/spf13/cobra"
+)
+
+var cosignCmdFlags = struct {
+	privateKey      string
+	signer          string
+	targetDirectory string
+	overwrite       bool
+}{}
+
+// cosignCmd represents the cosign command
+var cosignCmd = &cobra.Command{
+	Use:   "cosign [signed-licence-file]",
+	Short: "Add a signature to an already signed JSON licence",
+	Long: `Add a signature to an already signed JSON licence.
+
+The signature is appended to the licence's cosignatures. Use licence verify
+--policy to require signatures from several keys.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		target, err := replacementPath(args[0], cosignCmdFlags.targetDirectory, constant.SIGNED_LICENCE_FILE_NAME, cosignCmdFlags.overwrite)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		private, err := loadSigner(cosignCmdFlags.signer, cosignCmdFlags.privateKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		signedBytes, err := fs.ReadFile(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		format, err := licence.DetectFormat(signedBytes)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if format != licence.JSON {
+			log.Fatal("only JSON licences can be cosigned")
+		}
+
+		var signed licence.SignedLicence
+		err = json.Unmarshal(signedBytes, &signed)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		cosigned, err := licence.CosignLicence(private, signed)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		cosignedBytes, err := json.MarshalIndent(cosigned, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = fs.SaveCreateIntermediate(target, cosignedBytes, cosignCmdFlags.overwrite)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	licenceCmd.AddCommand(cosignCmd)
+
+	cosignCmd.Flags().StringVarP(&cosignCmdFlags.privateKey, "private-key", "k", constant.PRIVATE_KEY_FILE_NAME, "Private key used to cosign the licence")
+	cosignCmd.Flags().StringVar(&cosignCmdFlags.signer, "signer", "", "Signer backend used instead of the private key file, e.g. exec:/path/to/helper")
+	cosignCmd.Flags().StringVarP(&cosignCmdFlags.targetDirectory, "target-directory", "d", "", "Directory used to save the cosigned licence (default replaces the input, which requires --overwrite, or stdout for -)")
+	cosignCmd.Flags().BoolVarP(&cosignCmdFlags.overwrite, "overwrite", "o", false, "Overwrite existing files with generated files")
+}
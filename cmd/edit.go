@@ -0,0 +1,59 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/spf13/cobra"
+)
+
+var editCmdFlags = struct {
+	reissueFlags
+	set []string
+}{}
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit [signed-licence-file]",
+	Short: "Change fields of a signed licence and re-sign it",
+	Long: `Change fields of a signed licence and re-sign it.
+
+The licence is verified with the issuer's key first. The edited licence keeps
+its licence key and records the SHA-256 of the previous signature as its
+predecessor. Settable fields: ` + strings.Join(licence.Fields[1:], ", ") + `.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(editCmdFlags.set) == 0 {
+			log.Fatal("nothing to change, use --set field=value")
+		}
+		reissueLicence(args[0], editCmdFlags.reissueFlags, func(l licence.Licence) (licence.Licence, error) {
+			for _, assignment := range editCmdFlags.set {
+				field, value, ok := strings.Cut(assignment, "=")
+				if !ok {
+					return licence.Licence{}, fmt.Errorf("invalid --set '%s', expected field=value", assignment)
+				}
+				if field == "licence_key" {
+					return licence.Licence{}, errors.New("licence_key cannot be changed")
+				}
+				err := licence.SetField(&l, field, value)
+				if err != nil {
+					return licence.Licence{}, err
+				}
+			}
+			return l, nil
+		})
+	},
+}
+
+func init() {
+	licenceCmd.AddCommand(editCmd)
+
+	editCmdFlags.register(editCmd)
+	editCmd.Flags().StringArrayVarP(&editCmdFlags.set, "set", "s", nil, "Field to change as field=value, may be repeated")
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto"
+	"log"
+	"os"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/signer"
+	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
+)
+
+var exportKeyFlags = struct {
+	key       string
+	signer    string
+	format    key.ExportFormat
+	output    string
+	overwrite bool
+}{}
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a public key in the specified format",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var public crypto.PublicKey
+		if exportKeyFlags.signer != "" {
+			s, err := signer.Open(exportKeyFlags.signer)
+			if err != nil {
+				log.Fatal(err)
+			}
+			public = s.Public()
+		} else {
+			keyBytes, err := fs.ReadFile(exportKeyFlags.key)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			public, err = key.ParsePublicKey(keyBytes)
+			if err != nil {
+				s, signerErr := loadSigner("", exportKeyFlags.key)
+				if signerErr != nil {
+					log.Fatal(err)
+				}
+				public = s.Public()
+			}
+		}
+
+		exported, err := key.ExportPublicKey(public, exportKeyFlags.format)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if exportKeyFlags.output == "" {
+			_, err = os.Stdout.Write(exported)
+		} else {
+			err = fs.SaveCreateIntermediate(exportKeyFlags.output, exported, exportKeyFlags.overwrite)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	keyCmd.AddCommand(exportCmd)
+
+	fe := enumflag.New(
+		&exportKeyFlags.format,
+		"format",
+		key.ExportFormats,
+		enumflag.EnumCaseInsensitive,
+	)
+	fe.RegisterCompletion(exportCmd, "format", key.ExportFormatDescription)
+
+	exportCmd.Flags().StringVarP(&exportKeyFlags.key, "key", "k", constant.PRIVATE_KEY_FILE_NAME, "Private or public key to export the public key from")
+	exportCmd.Flags().StringVar(&exportKeyFlags.signer, "signer", "", "Signer backend to export the public key from, e.g. exec:/path/to/helper")
+	exportCmd.Flags().VarP(fe, "format", "f", "Format of the exported public key")
+	exportCmd.Flags().StringVarP(&exportKeyFlags.output, "output", "O", "", "File to write the exported key to (default stdout)")
+	exportCmd.Flags().BoolVarP(&exportKeyFlags.overwrite, "overwrite", "o", false, "Overwrite the output file if it exists")
+}
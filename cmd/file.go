@@ -0,0 +1,18 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// fileCmd represents the file command
+var fileCmd = &cobra.Command{
+	Use:   "file",
+	Short: "Sign and verify arbitrary files using detached signatures",
+}
+
+func init() {
+	rootCmd.AddCommand(fileCmd)
+}
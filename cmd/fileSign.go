@@ -0,0 +1,113 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto"
+	"log"
+	"path/filepath"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/detached"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/sign"
+	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
+)
+
+var fileSignCmdFlags = struct {
+	privateKey       string
+	signer           string
+	targetDirectory  string
+	out              string
+	overwrite        bool
+	format           detached.Format
+	trustedComment   string
+	untrustedComment string
+	namespace        string
+	agentKey         string
+	agentSocket      string
+	tsa              string
+}{}
+
+// fileSignCmd represents the file sign command
+var fileSignCmd = &cobra.Command{
+	Use:   "sign [file]",
+	Short: "Create a detached signature for a file using the specified private key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var private crypto.PrivateKey
+		if fileSignCmdFlags.agentKey != "" {
+			agentSigner, err := sign.NewAgentSigner(fileSignCmdFlags.agentSocket, fileSignCmdFlags.agentKey)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer agentSigner.Close()
+			private = agentSigner
+		} else {
+			var err error
+			private, err = loadSigner(fileSignCmdFlags.signer, fileSignCmdFlags.privateKey)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		data, err := fs.ReadFile(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		signature, err := detached.Sign(private, data, fileSignCmdFlags.format, detached.SignOptions{
+			FileName:         args[0],
+			TrustedComment:   fileSignCmdFlags.trustedComment,
+			UntrustedComment: fileSignCmdFlags.untrustedComment,
+			Namespace:        fileSignCmdFlags.namespace,
+			TSA:              fileSignCmdFlags.tsa,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		signaturePath := fileSignCmdFlags.out
+		if signaturePath == "" {
+			if args[0] == constant.STANDARD_STREAM && fileSignCmdFlags.targetDirectory == "" {
+				log.Fatal("--out or --target-directory is required when the file is read from stdin")
+			}
+			if fileSignCmdFlags.targetDirectory == "" {
+				fileSignCmdFlags.targetDirectory = filepath.Dir(args[0])
+			}
+			signaturePath = filepath.Join(fileSignCmdFlags.targetDirectory, filepath.Base(args[0])+detached.Extensions[fileSignCmdFlags.format])
+		}
+
+		err = fs.SaveCreateIntermediate(signaturePath, signature, fileSignCmdFlags.overwrite)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	fileCmd.AddCommand(fileSignCmd)
+
+	fe := enumflag.New(
+		&fileSignCmdFlags.format,
+		"format",
+		detached.Formats,
+		enumflag.EnumCaseInsensitive,
+	)
+	fe.RegisterCompletion(fileSignCmd, "format", detached.FormatDescription)
+
+	fileSignCmd.Flags().StringVarP(&fileSignCmdFlags.privateKey, "private-key", "k", constant.PRIVATE_KEY_FILE_NAME, "Private key used to sign the file")
+	fileSignCmd.Flags().StringVar(&fileSignCmdFlags.signer, "signer", "", "Signer backend used instead of the private key file, e.g. exec:/path/to/helper")
+	fileSignCmd.Flags().StringVarP(&fileSignCmdFlags.targetDirectory, "target-directory", "d", "", "Directory used to save the signature. (default $file_directory)")
+	fileSignCmd.Flags().StringVar(&fileSignCmdFlags.out, "out", "", "File the signature is written to instead of the target directory, - for stdout")
+	fileSignCmd.Flags().BoolVarP(&fileSignCmdFlags.overwrite, "overwrite", "o", false, "Overwrite an existing signature")
+	fileSignCmd.Flags().VarP(fe, "format", "f", "Signature format")
+	fileSignCmd.Flags().StringVarP(&fileSignCmdFlags.trustedComment, "trusted-comment", "t", "", "Signed comment stored in minisign signatures (default timestamp and file name)")
+	fileSignCmd.Flags().StringVarP(&fileSignCmdFlags.untrustedComment, "untrusted-comment", "c", "", "Unsigned comment stored in minisign signatures")
+	fileSignCmd.Flags().StringVarP(&fileSignCmdFlags.namespace, "namespace", "n", detached.DEFAULT_NAMESPACE, "Namespace of SSH signatures")
+	fileSignCmd.Flags().StringVar(&fileSignCmdFlags.agentKey, "agent-key", "", "Sign with the ssh-agent key with this fingerprint instead of a private key file (sshsig only)")
+	fileSignCmd.Flags().StringVar(&fileSignCmdFlags.agentSocket, "agent-socket", "", "ssh-agent socket used with --agent-key (default $SSH_AUTH_SOCK)")
+	fileSignCmd.Flags().StringVar(&fileSignCmdFlags.tsa, "tsa", "", "URL of an RFC 3161 time-stamping authority used to timestamp the signature (native only)")
+}
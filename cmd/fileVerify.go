@@ -0,0 +1,118 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/detached"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+)
+
+var fileVerifyCmdFlags = struct {
+	publicKey      string
+	signature      string
+	namespace      string
+	allowedSigners string
+	identity       string
+	tsaRoots       string
+	revokedAt      string
+}{}
+
+// fileVerifyCmd represents the file verify command
+var fileVerifyCmd = &cobra.Command{
+	Use:   "verify [file]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Verify a detached file signature using public key",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := fs.ReadFile(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		signaturePath := fileVerifyCmdFlags.signature
+		if signaturePath == "" && args[0] == constant.STANDARD_STREAM {
+			log.Fatal("--signature is required when the file is read from stdin")
+		}
+		if signaturePath == "" {
+			signaturePath, err = findSignature(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		signature, err := fs.ReadFile(signaturePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		timestampOptions, err := loadTimestampOptions(fileVerifyCmdFlags.tsaRoots, fileVerifyCmdFlags.revokedAt)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		options := detached.VerifyOptions{
+			Namespace: fileVerifyCmdFlags.namespace,
+			Identity:  fileVerifyCmdFlags.identity,
+			Timestamp: timestampOptions,
+		}
+		var publicBytes []byte
+		if fileVerifyCmdFlags.allowedSigners != "" {
+			options.AllowedSigners, err = fs.ReadFile(fileVerifyCmdFlags.allowedSigners)
+		} else {
+			publicBytes, err = fs.ReadFile(fileVerifyCmdFlags.publicKey)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = detached.Verify(data, signature, publicBytes, options)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Print("Signature valid")
+	},
+}
+
+func findSignature(path string) (string, error) {
+	formats := maps.Keys(detached.Extensions)
+	slices.Sort(formats)
+	candidates := make([]string, 0, len(formats))
+	for _, format := range formats {
+		candidate := path + detached.Extensions[format]
+		exists, typ, err := fs.Exists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if exists && typ == fs.File {
+			return candidate, nil
+		}
+		candidates = append(candidates, candidate)
+	}
+	return "", fmt.Errorf("no signature found, tried '%s'", strings.Join(candidates, "', '"))
+}
+
+func init() {
+	fileCmd.AddCommand(fileVerifyCmd)
+
+	fileVerifyCmd.Flags().StringVarP(&fileVerifyCmdFlags.publicKey,
+		"public-key", "k", constant.PUBLIC_KEY_FILE_NAME, "Public key (PEM, minisign or OpenSSH) used for verifying the signature")
+	fileVerifyCmd.Flags().StringVarP(&fileVerifyCmdFlags.signature,
+		"signature", "s", "", "Detached signature file (default $file.sig, $file.minisig or $file.sshsig)")
+	fileVerifyCmd.Flags().StringVarP(&fileVerifyCmdFlags.namespace,
+		"namespace", "n", detached.DEFAULT_NAMESPACE, "Namespace expected in SSH signatures")
+	fileVerifyCmd.Flags().StringVarP(&fileVerifyCmdFlags.allowedSigners,
+		"allowed-signers", "a", "", "OpenSSH allowed_signers file used instead of a public key to trust SSH signatures")
+	fileVerifyCmd.Flags().StringVarP(&fileVerifyCmdFlags.identity,
+		"identity", "I", "", "Principal the signer must be listed as in the allowed signers file")
+	fileVerifyCmd.Flags().StringVar(&fileVerifyCmdFlags.tsaRoots,
+		"tsa-roots", "", "PEM bundle of trusted time-stamping authority roots (default system roots)")
+	fileVerifyCmd.Flags().StringVar(&fileVerifyCmdFlags.revokedAt,
+		"revoked-at", "", "Time the signing key was revoked (yyyy-mm-dd or RFC 3339). Only native signatures timestamped before it are accepted")
+}
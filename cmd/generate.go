@@ -16,6 +16,8 @@ import (
 
 var generateKeyFlags = struct {
 	targetDirectory string
+	out             string
+	publicOut       string
 	keyType         key.KeyType
 	bitsize         uint
 	overwrite       bool
@@ -36,14 +38,21 @@ var generateCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		err = fs.SaveCreateIntermediate(
-			filepath.Join(generateKeyFlags.targetDirectory, constant.PRIVATE_KEY_FILE_NAME), privateBytes, generateKeyFlags.overwrite)
+		privatePath := generateKeyFlags.out
+		if privatePath == "" {
+			privatePath = filepath.Join(generateKeyFlags.targetDirectory, constant.PRIVATE_KEY_FILE_NAME)
+		}
+		publicPath := generateKeyFlags.publicOut
+		if publicPath == "" {
+			publicPath = filepath.Join(generateKeyFlags.targetDirectory, constant.PUBLIC_KEY_FILE_NAME)
+		}
+
+		err = fs.SaveCreateIntermediate(privatePath, privateBytes, generateKeyFlags.overwrite)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		err = fs.SaveCreateIntermediate(
-			filepath.Join(generateKeyFlags.targetDirectory, constant.PUBLIC_KEY_FILE_NAME), publicBytes, generateKeyFlags.overwrite)
+		err = fs.SaveCreateIntermediate(publicPath, publicBytes, generateKeyFlags.overwrite)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -62,6 +71,8 @@ func init() {
 	te.RegisterCompletion(generateCmd, "type", key.KeyTypeDescription)
 
 	generateCmd.Flags().StringVarP(&generateKeyFlags.targetDirectory, "target-directory", "d", ".", "Directory used to save generated key pair")
+	generateCmd.Flags().StringVar(&generateKeyFlags.out, "out", "", "File the private key is written to instead of the target directory, - for stdout")
+	generateCmd.Flags().StringVar(&generateKeyFlags.publicOut, "public-out", "", "File the public key is written to instead of the target directory, - for stdout")
 	generateCmd.Flags().BoolVarP(&generateKeyFlags.overwrite, "overwrite", "o", false, "Overwrite existing files with generated files")
 	generateCmd.Flags().VarP(te, "type", "t", "Algorithm used for generating private/public key pairs")
 	generateCmd.Flags().UintVarP(&generateKeyFlags.bitsize, "bit-size", "b", 4096, "Number of bits used if RSA algorithm is used (must be a multiple of 256)")
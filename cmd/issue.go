@@ -0,0 +1,204 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/eslam-allam/file-signer/internal/registry"
+	"github.com/eslam-allam/file-signer/internal/translog"
+	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
+)
+
+// issueFlags are shared by the commands that sign a new licence.
+type issueFlags struct {
+	privateKey      string
+	signer          string
+	targetDirectory string
+	out             string
+	overwrite       bool
+	format          licence.Format
+	omitKeyId       bool
+	tsa             string
+	log             string
+	noRegistry      bool
+	policy          string
+	profile         string
+}
+
+var signedLicenceFileNames = map[licence.Format]string{
+	licence.JSON:     constant.SIGNED_LICENCE_FILE_NAME,
+	licence.PEM:      constant.SIGNED_LICENCE_PEM_FILE_NAME,
+	licence.JWS:      constant.SIGNED_LICENCE_JWS_FILE_NAME,
+	licence.JWS_JSON: constant.SIGNED_LICENCE_JWS_JSON_FILE_NAME,
+	licence.COSE:     constant.SIGNED_LICENCE_COSE_FILE_NAME,
+	licence.PASETO:   constant.SIGNED_LICENCE_PASETO_FILE_NAME,
+}
+
+// register adds the signing, format and output flags to cmd.
+func (f *issueFlags) register(cmd *cobra.Command, targetDirectoryDefault, targetDirectoryUsage string) {
+	f.registerSigning(cmd)
+	f.registerFormat(cmd)
+	cmd.Flags().StringVarP(&f.targetDirectory, "target-directory", "d", targetDirectoryDefault, targetDirectoryUsage)
+	cmd.Flags().StringVar(&f.out, "out", "", "File the signed licence is written to instead of the target directory, - for stdout")
+	cmd.Flags().BoolVarP(&f.overwrite, "overwrite", "o", false, "Overwrite existing files with generated files")
+}
+
+// registerFormat adds the flag selecting the signed licence encoding to cmd.
+func (f *issueFlags) registerFormat(cmd *cobra.Command) {
+	fe := enumflag.New(
+		&f.format,
+		"format",
+		licence.Formats,
+		enumflag.EnumCaseInsensitive,
+	)
+	fe.RegisterCompletion(cmd, "format", licence.FormatDescription)
+	cmd.Flags().VarP(fe, "format", "f", "Encoding used for the signed licence")
+}
+
+// registerSigning adds the flags controlling how a licence is signed and
+// recorded to cmd.
+func (f *issueFlags) registerSigning(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.privateKey, "private-key", "k", constant.PRIVATE_KEY_FILE_NAME, "Private key used to sign the licence")
+	cmd.Flags().StringVar(&f.signer, "signer", "", "Signer backend used instead of the private key file, e.g. exec:/path/to/helper")
+	cmd.Flags().BoolVar(&f.omitKeyId, "omit-key-id", false, "Do not embed the signing key fingerprint in the signed licence")
+	cmd.Flags().StringVar(&f.tsa, "tsa", "", "URL of an RFC 3161 time-stamping authority used to timestamp the signature (json and pem only)")
+	cmd.Flags().StringVar(&f.policy, "policy", "", "Issuance policy the licence must satisfy (default "+constant.ISSUANCE_POLICY_FILE_NAME+" if present)")
+	cmd.Flags().StringVar(&f.profile, "profile", "", "Issuance policy profile whose defaults fill empty licence fields, e.g. trial")
+	cmd.Flags().BoolVar(&f.noRegistry, "no-registry", false, "Do not record the signed licence in the licence registry")
+	cmd.Flags().StringVar(&f.log, "log", "", "Transparency log directory the signed licence is appended to, e.g. "+constant.TRANSPARENCY_LOG_DIRECTORY)
+}
+
+// checkOutput fails when path exists and may not be overwritten, so that
+// nothing is signed or logged for a licence that cannot be saved.
+func checkOutput(path string, overwrite bool) error {
+	if path == constant.STANDARD_STREAM || overwrite {
+		return nil
+	}
+	exists, _, err := fs.Exists(path)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("path '%s' already exists and overwriting is not permitted", path)
+	}
+	return nil
+}
+
+// issuer signs licences for issueFlags. The signer, the registry and the
+// transparency log are opened once, before anything is signed, so that a
+// missing key or a locked registry fails before a licence is issued.
+type issuer struct {
+	flags    *issueFlags
+	private  crypto.Signer
+	registry *registry.Registry
+	log      *translog.Log
+	logLock  sync.Mutex
+}
+
+func (f *issueFlags) openIssuer() (*issuer, error) {
+	private, err := loadSigner(f.signer, f.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	i := &issuer{flags: f, private: private}
+	if f.log != "" {
+		i.log, err = translog.Open(f.log)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !f.noRegistry {
+		i.registry, err = openRegistry()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return i, nil
+}
+
+func (i *issuer) Close() error {
+	if i.registry == nil {
+		return nil
+	}
+	return i.registry.Close()
+}
+
+// sign signs the prepared licence l in format, appends it to the
+// transparency log, saves it to signedPath and records it. It is safe to
+// call concurrently.
+//
+// The licence is logged before it is saved so that a failing log cannot
+// leave an issued licence that auditors never see.
+func (i *issuer) sign(l licence.Licence, format licence.Format, signedPath string) ([]byte, error) {
+	signedBytes, err := licence.SignLicenceAs(i.private, l, format, licence.SignOptions{
+		OmitKeyId: i.flags.omitKeyId,
+		TSA:       i.flags.tsa,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if i.log != nil {
+		i.logLock.Lock()
+		_, err = i.log.Append(i.private, signedBytes)
+		i.logLock.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = fs.SaveCreateIntermediate(signedPath, signedBytes, i.flags.overwrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if i.registry != nil {
+		err = recordLicence(i.registry, l, format, i.private.Public(), signedPath, signedBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return signedBytes, nil
+}
+
+// issue enforces the issuance policy on l and signs it to the output path or
+// the target directory. It returns the path of the signed licence.
+func (f *issueFlags) issue(l licence.Licence) (string, error) {
+	prepare, err := issuancePreparer(f.policy, f.profile)
+	if err != nil {
+		return "", err
+	}
+	l, err = prepare(l)
+	if err != nil {
+		return "", err
+	}
+
+	signedPath := f.out
+	if signedPath == "" {
+		signedPath = filepath.Join(f.targetDirectory, signedLicenceFileNames[f.format])
+	}
+	err = checkOutput(signedPath, f.overwrite)
+	if err != nil {
+		return "", err
+	}
+
+	i, err := f.openIssuer()
+	if err != nil {
+		return "", err
+	}
+	defer i.Close()
+	_, err = i.sign(l, f.format, signedPath)
+	if err != nil {
+		return "", err
+	}
+	return signedPath, nil
+}
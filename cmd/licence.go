@@ -7,6 +7,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var licenceCmdFlags = struct {
+	registry string
+}{}
+
 // licenceCmd represents the licence command
 var licenceCmd = &cobra.Command{
 	Use:   "licence",
@@ -15,4 +19,7 @@ var licenceCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(licenceCmd)
+
+	licenceCmd.PersistentFlags().StringVar(&licenceCmdFlags.registry,
+		"registry", "", "Licence registry database (default $config_dir/file-signer/registry.db)")
 }
@@ -0,0 +1,251 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
+)
+
+var diffCmdFlags = struct {
+	publicKeys []string
+	signer     string
+	product    string
+	output     outputFormat
+}{}
+
+// diffLicence describes one side of a licence diff.
+type diffLicence struct {
+	File   string `json:"file"`
+	Format string `json:"format"`
+	Signed bool   `json:"signed"`
+	KeyId  string `json:"key_id,omitempty"`
+	// Signature is "valid", "not verified", "unsigned" or the verification
+	// error.
+	Signature            string `json:"signature"`
+	SignatureFingerprint string `json:"signature_fingerprint,omitempty"`
+}
+
+type diffResult struct {
+	A diffLicence `json:"a"`
+	B diffLicence `json:"b"`
+	// SameKey is unset when the signing keys of the licences are not known.
+	SameKey *bool `json:"same_key"`
+	// Renews is set when b names a as its predecessor.
+	Renews  bool             `json:"renews"`
+	Changes []licence.Change `json:"changes"`
+}
+
+// diffCmd represents the licence diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <a> <b>",
+	Short: "Compare two licence files field by field",
+	Long: `Compare two licence files field by field.
+
+Both licences may be signed, in any format, or unsigned licence templates.
+Every field that was added, removed or modified from a to b is listed, with
+features compared one by one and a feature claim such as seats=5 shown as
+modified when only its value changed.
+
+The signing key of each licence is the one it names, or the --public-key that
+verifies it, so licences in formats that do not name their key can still be
+compared. b is reported as a renewal of a when it names a as its predecessor.`,
+	Example: `  file-signer licence diff old/licence.json new/licence.json -k public.pem
+  file-signer licence diff a.jws b.jws --output json`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		verifier, err := loadDiffVerifier()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var result diffResult
+		var licences [2]licence.Licence
+		var fingerprints [2]string
+		for i, side := range []*diffLicence{&result.A, &result.B} {
+			*side, licences[i], err = describeDiffLicence(args[i], verifier)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fingerprints[i] = side.SignatureFingerprint
+		}
+
+		result.SameKey = sameKey(result.A, result.B)
+		result.Renews = fingerprints[0] != "" && licences[1].Predecessor == fingerprints[0]
+		result.Changes = licence.Diff(licences[0], licences[1])
+		if result.Changes == nil {
+			result.Changes = []licence.Change{}
+		}
+
+		if diffCmdFlags.output == OUTPUT_JSON {
+			resultBytes, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(string(resultBytes))
+			return
+		}
+		printDiff(result)
+	},
+}
+
+func loadDiffVerifier() (licenceVerifier, error) {
+	verifier := licenceVerifier{now: time.Now(), options: licence.VerifyOptions{Product: diffCmdFlags.product}}
+	publicKeys := diffCmdFlags.publicKeys
+	if diffCmdFlags.signer != "" {
+		publicKeys = []string{""}
+	}
+	for _, publicKeyPath := range publicKeys {
+		publicKey, err := loadPublicKey(diffCmdFlags.signer, publicKeyPath)
+		if err != nil {
+			return licenceVerifier{}, err
+		}
+		keyId, err := key.Fingerprint(publicKey)
+		if err != nil {
+			return licenceVerifier{}, err
+		}
+		verifier.keys = append(verifier.keys, verificationKey{publicKey: publicKey, keyId: keyId})
+	}
+	return verifier, nil
+}
+
+// describeDiffLicence decodes the licence at path and identifies its signing
+// key, verifying it when keys were given.
+func describeDiffLicence(path string, verifier licenceVerifier) (diffLicence, licence.Licence, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return diffLicence{}, licence.Licence{}, err
+	}
+	format, err := licence.DetectFormat(data)
+	if err != nil {
+		return diffLicence{}, licence.Licence{}, fmt.Errorf("%s: %w", path, err)
+	}
+	l, keyId, err := licence.Decode(data)
+	if err != nil {
+		return diffLicence{}, licence.Licence{}, fmt.Errorf("%s: %w", path, err)
+	}
+	side := diffLicence{File: path, Format: licence.Formats[format][0], KeyId: keyId, Signature: "unsigned"}
+
+	side.SignatureFingerprint, err = licence.SignatureFingerprint(data)
+	if errors.Is(err, licence.ErrUnsigned) {
+		return side, l, nil
+	}
+	if err != nil {
+		return diffLicence{}, licence.Licence{}, fmt.Errorf("%s: %w", path, err)
+	}
+	side.Signed = true
+	side.Signature = "not verified"
+	if len(verifier.keys) > 0 {
+		_, verifiedKeyId, err := verifier.verifySignature(data)
+		side.Signature = "valid"
+		if err != nil {
+			side.Signature = err.Error()
+		} else {
+			side.KeyId = verifiedKeyId
+		}
+	}
+	return side, l, nil
+}
+
+// sameKey reports whether a and b were signed by the same key, or nil when
+// that is not known.
+func sameKey(a, b diffLicence) *bool {
+	var same bool
+	switch {
+	case !a.Signed && !b.Signed:
+		return nil
+	case a.Signed != b.Signed:
+		same = false
+	case a.KeyId == "" || b.KeyId == "":
+		return nil
+	default:
+		same = a.KeyId == b.KeyId
+	}
+	return &same
+}
+
+func describeDiffSigner(side diffLicence) string {
+	switch {
+	case !side.Signed:
+		return "unsigned"
+	case side.KeyId == "":
+		return fmt.Sprintf("signed by an unnamed key, signature %s", side.Signature)
+	default:
+		return fmt.Sprintf("signed by %s, signature %s", side.KeyId, side.Signature)
+	}
+}
+
+func printDiff(result diffResult) {
+	fmt.Printf("a: %s (%s, %s)\n", result.A.File, result.A.Format, describeDiffSigner(result.A))
+	fmt.Printf("b: %s (%s, %s)\n", result.B.File, result.B.Format, describeDiffSigner(result.B))
+	switch {
+	case result.SameKey == nil && !result.A.Signed && !result.B.Signed:
+		fmt.Println("Signing key: neither licence is signed")
+	case result.SameKey == nil:
+		fmt.Println("Signing key: unknown, pass --public-key to identify it")
+	case result.A.Signed && !result.B.Signed:
+		fmt.Println("Signing key: different, only a is signed")
+	case !result.A.Signed && result.B.Signed:
+		fmt.Println("Signing key: different, only b is signed")
+	case *result.SameKey:
+		fmt.Println("Signing key: same")
+	default:
+		fmt.Println("Signing key: different")
+	}
+	if result.Renews {
+		fmt.Println("Renewal:     b names a as its predecessor")
+	}
+
+	if len(result.Changes) == 0 {
+		fmt.Println("No changes")
+		return
+	}
+	fmt.Println("Changes:")
+	for _, change := range result.Changes {
+		// Feature changes carry the whole feature, which names itself.
+		field := change.Field
+		if strings.HasPrefix(field, "features.") {
+			field = "features"
+		}
+		switch change.Kind {
+		case licence.CHANGE_ADDED:
+			fmt.Printf("  + %s: %s\n", field, change.New)
+		case licence.CHANGE_REMOVED:
+			fmt.Printf("  - %s: %s\n", field, change.Old)
+		default:
+			fmt.Printf("  ~ %s: %s -> %s\n", field, change.Old, change.New)
+		}
+	}
+}
+
+func init() {
+	licenceCmd.AddCommand(diffCmd)
+
+	oe := enumflag.New(
+		&diffCmdFlags.output,
+		"output",
+		outputFormats,
+		enumflag.EnumCaseInsensitive,
+	)
+	oe.RegisterCompletion(diffCmd, "output", outputFormatDescription)
+
+	diffCmd.Flags().StringArrayVarP(&diffCmdFlags.publicKeys,
+		"public-key", "k", nil, "Public key used to verify the licences and identify their signing key (repeatable)")
+	diffCmd.Flags().StringVar(&diffCmdFlags.signer,
+		"signer", "", "Signer backend whose public key is used instead of the public key files, e.g. vault:licence-key")
+	diffCmd.Flags().StringVarP(&diffCmdFlags.product,
+		"product", "p", "", "Product the licences must be bound to, required to verify paseto licences")
+	diffCmd.Flags().Var(oe, "output", "Format of the comparison")
+	diffCmd.MarkFlagsMutuallyExclusive("public-key", "signer")
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/registry"
+	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
+)
+
+var licenceExportCmdFlags = struct {
+	format    registry.ExportFormat
+	output    string
+	overwrite bool
+}{}
+
+// licenceExportCmd represents the licence export command
+var licenceExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export licences recorded in the licence registry as CSV or JSON",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		records, err := searchRegistry()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		exported, err := registry.Export(records, licenceExportCmdFlags.format)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if licenceExportCmdFlags.output == "" {
+			_, err = os.Stdout.Write(exported)
+		} else {
+			err = fs.SaveCreateIntermediate(licenceExportCmdFlags.output, exported, licenceExportCmdFlags.overwrite)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	licenceCmd.AddCommand(licenceExportCmd)
+
+	fe := enumflag.New(
+		&licenceExportCmdFlags.format,
+		"format",
+		registry.ExportFormats,
+		enumflag.EnumCaseInsensitive,
+	)
+	fe.RegisterCompletion(licenceExportCmd, "format", registry.ExportFormatDescription)
+
+	licenceExportCmd.Flags().VarP(fe, "format", "f", "Format of the exported records")
+	licenceExportCmd.Flags().StringVarP(&licenceExportCmdFlags.output, "output", "O", "", "File to write the export to (default stdout)")
+	licenceExportCmd.Flags().BoolVarP(&licenceExportCmdFlags.overwrite, "overwrite", "o", false, "Overwrite the output file if it exists")
+	addQueryFlags(licenceExportCmd)
+}
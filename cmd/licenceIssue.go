@@ -0,0 +1,116 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"log"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var issueCmdFlags = struct {
+	issueFlags
+	licence  licence.Licence
+	features []string
+}{}
+
+// issueCmd represents the issue command
+var issueCmd = &cobra.Command{
+	Use:   "issue",
+	Short: "Sign a licence built from flags without a licence file",
+	Long: `Sign a licence built from flags without a licence file.
+
+The licence goes through the same validation, issuance policy and signing as
+licence sign and is written to stdout unless --out is given. Dates accept
+"today" and offsets such as "+1y"; the expiry offset counts from the issue
+date.`,
+	Example: `  file-signer licence issue --name "Jane Doe" --email jane@example.com \
+    --product editor --version 2 --issuer acme --expires +1y \
+    --feature export --feature seats=5 --key private.key`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		l := issueCmdFlags.licence
+		l.Schema = licence.New().Schema
+		l.Features = issueCmdFlags.features
+
+		var err error
+		l.IssueDate, err = licence.ResolveDate(l.IssueDate, time.Now())
+		if err != nil {
+			log.Fatalf("--issue-date: %v", err)
+		}
+		issued := time.Now()
+		if l.IssueDate != "" {
+			issued, _ = time.Parse(time.DateOnly, l.IssueDate)
+		}
+		l.ExpiryDate, err = licence.ResolveDate(l.ExpiryDate, issued)
+		if err != nil {
+			log.Fatalf("--expires: %v", err)
+		}
+
+		for _, field := range licence.SchemaFields() {
+			if field.Required && !cmd.Flags().Changed(issueFieldFlags[field.Name]) {
+				// Missing fields may still be filled by an issuance policy
+				// profile, so they are left to the signing validation.
+				continue
+			}
+			err = field.Validate(licence.GetField(l, field.Name))
+			if err != nil {
+				log.Fatalf("--%s: %v", issueFieldFlags[field.Name], err)
+			}
+		}
+
+		_, err = issueCmdFlags.issue(l)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+const featureFlagUsage = "Feature enabled by the licence as name or name=value, e.g. export or seats=5 (repeatable)"
+
+// issueFieldFlags maps licence fields to the issue flags that set them.
+var issueFieldFlags = map[string]string{
+	"licence_key": "licence-key",
+	"name":        "name",
+	"email":       "email",
+	"product":     "product",
+	"version":     "version",
+	"issuer":      "issuer",
+	"issue_date":  "issue-date",
+	"expiry_date": "expires",
+	"tier":        "tier",
+	"features":    "feature",
+}
+
+func init() {
+	licenceCmd.AddCommand(issueCmd)
+
+	issueCmdFlags.registerSigning(issueCmd)
+	issueCmdFlags.registerFormat(issueCmd)
+	issueCmd.Flags().StringVar(&issueCmdFlags.out, "out", constant.STANDARD_STREAM, "File the signed licence is written to, - for stdout")
+	issueCmd.Flags().BoolVarP(&issueCmdFlags.overwrite, "overwrite", "o", false, "Overwrite the output file if it exists")
+
+	l := &issueCmdFlags.licence
+	issueCmd.Flags().StringVar(&l.LicenceKey, "licence-key", "", "Licence key (default a random UUIDv4)")
+	issueCmd.Flags().StringVar(&l.Name, "name", "", "Name of purchaser")
+	issueCmd.Flags().StringVar(&l.Email, "email", "", "Email of purchaser")
+	issueCmd.Flags().StringVar(&l.Product, "product", "", "Name of the applicable product")
+	issueCmd.Flags().StringVar(&l.Version, "version", "", "Version of the licence")
+	issueCmd.Flags().StringVar(&l.Issuer, "issuer", "", "Issuer of the licence")
+	issueCmd.Flags().StringVar(&l.IssueDate, "issue-date", "", "Issue date as yyyy-mm-dd or today (default today)")
+	issueCmd.Flags().StringVar(&l.ExpiryDate, "expires", "", "Expiry date as yyyy-mm-dd or an offset from the issue date such as +1y")
+	issueCmd.Flags().StringVar(&l.Tier, "tier", "", "Tier of the licence")
+	issueCmd.Flags().StringArrayVar(&issueCmdFlags.features, "feature", nil, featureFlagUsage)
+
+	issueCmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "key" {
+			name = "private-key"
+		}
+		return pflag.NormalizedName(name)
+	})
+}
@@ -0,0 +1,160 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eslam-allam/file-signer/internal/certificate"
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
+)
+
+var renderCmdFlags = struct {
+	format          certificate.Format
+	layout          string
+	template        string
+	logo            string
+	title           string
+	publicKey       string
+	signer          string
+	product         string
+	targetDirectory string
+	out             string
+	overwrite       bool
+}{}
+
+// renderCmd represents the licence render command
+var renderCmd = &cobra.Command{
+	Use:   "render [signed-licence]",
+	Short: "Render a signed licence as an HTML or PDF certificate",
+	Long: `Render a signed licence as an HTML or PDF certificate.
+
+The certificate shows the licence fields, the SHA-256 fingerprint of the
+signature and a QR code holding the signed licence in its compact form, so it
+can be scanned and verified with the issuer's public key.
+
+--layout names a JSON file customising the certificate:
+
+  {
+    "title": "Licence Certificate",
+    "subtitle": "This certifies that",
+    "footer": "...",
+    "logo": "logo.png",
+    "accent_color": "#1f4e79",
+    "page_size": "A4",
+    "orientation": "landscape"
+  }
+
+The logo is a PNG or JPEG file relative to the layout file. For HTML,
+--template replaces the page with a Go html/template given the licence fields,
+Title, Subtitle, Footer, AccentColor, PageSize, Orientation, Format, KeyId,
+SignatureFingerprint and the QRCodeURL and LogoURL data URIs.
+
+The certificate is written next to the licence unless --target-directory or
+--out is given. When --public-key or --signer is given the licence must verify
+before it is rendered.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := args[0]
+		data, err := fs.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if renderCmdFlags.publicKey != "" || renderCmdFlags.signer != "" {
+			publicKey, err := loadPublicKey(renderCmdFlags.signer, renderCmdFlags.publicKey)
+			if err != nil {
+				log.Fatal(err)
+			}
+			_, err = licence.VerifyLicence(data, publicKey, licence.VerifyOptions{Product: renderCmdFlags.product})
+			if err != nil {
+				log.Print(err)
+				os.Exit(exitCode(err))
+			}
+		}
+
+		options := certificate.Options{}
+		if renderCmdFlags.layout != "" {
+			options.Layout, err = certificate.LoadLayout(renderCmdFlags.layout)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		if renderCmdFlags.logo != "" {
+			options.Layout.Logo = renderCmdFlags.logo
+		}
+		if renderCmdFlags.title != "" {
+			options.Layout.Title = renderCmdFlags.title
+		}
+		if renderCmdFlags.template != "" {
+			text, err := fs.ReadFile(renderCmdFlags.template)
+			if err != nil {
+				log.Fatal(err)
+			}
+			options.Template = string(text)
+		}
+
+		rendered, err := certificate.Render(data, renderCmdFlags.format, options)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = fs.SaveCreateIntermediate(certificatePath(path), rendered, renderCmdFlags.overwrite)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// certificatePath is where the certificate for the licence at path is written.
+func certificatePath(path string) string {
+	if renderCmdFlags.out != "" {
+		return renderCmdFlags.out
+	}
+	if path == constant.STANDARD_STREAM && renderCmdFlags.targetDirectory == "" {
+		return constant.STANDARD_STREAM
+	}
+	name := "licence"
+	directory := renderCmdFlags.targetDirectory
+	if path != constant.STANDARD_STREAM {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if directory == "" {
+			directory = filepath.Dir(path)
+		}
+	}
+	return filepath.Join(directory, name+certificate.Extensions[renderCmdFlags.format])
+}
+
+func init() {
+	licenceCmd.AddCommand(renderCmd)
+
+	fe := enumflag.New(
+		&renderCmdFlags.format,
+		"format",
+		certificate.Formats,
+		enumflag.EnumCaseInsensitive,
+	)
+	fe.RegisterCompletion(renderCmd, "format", certificate.FormatDescription)
+
+	renderCmd.Flags().VarP(fe, "format", "f", "Format of the certificate")
+	renderCmd.Flags().StringVar(&renderCmdFlags.layout, "layout", "", "JSON file customising the title, logo, colours and page of the certificate")
+	renderCmd.Flags().StringVarP(&renderCmdFlags.template, "template", "t", "", "Go html/template file replacing the HTML certificate")
+	renderCmd.Flags().StringVar(&renderCmdFlags.logo, "logo", "", "PNG or JPEG logo, overriding the layout")
+	renderCmd.Flags().StringVar(&renderCmdFlags.title, "title", "", "Title of the certificate, overriding the layout")
+	renderCmd.Flags().StringVarP(&renderCmdFlags.publicKey, "public-key", "k", "", "Public key the licence must verify with before rendering")
+	renderCmd.Flags().StringVar(&renderCmdFlags.signer, "signer", "", "Signer backend whose public key the licence must verify with, e.g. vault:licence-key")
+	renderCmd.Flags().StringVarP(&renderCmdFlags.product, "product", "p", "", "Product the licence must be bound to, required to verify paseto licences")
+	renderCmd.Flags().StringVarP(&renderCmdFlags.targetDirectory, "target-directory", "d", "", "Directory to write the certificate to (default the licence's directory)")
+	renderCmd.Flags().StringVar(&renderCmdFlags.out, "out", "", "File the certificate is written to, - for stdout")
+	renderCmd.Flags().BoolVarP(&renderCmdFlags.overwrite, "overwrite", "o", false, "Overwrite the certificate if it exists")
+	renderCmd.MarkFlagsMutuallyExclusive("public-key", "signer")
+	renderCmd.MarkFlagsMutuallyExclusive("target-directory", "out")
+}
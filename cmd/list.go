@@ -0,0 +1,32 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every licence recorded in the licence registry",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		records, err := searchRegistry()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = printRecords(os.Stdout, records)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	licenceCmd.AddCommand(listCmd)
+}
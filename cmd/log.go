@@ -0,0 +1,18 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// logCmd represents the log command
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Do various transparency log operations",
+}
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+}
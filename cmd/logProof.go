@@ -0,0 +1,70 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"log"
+	"path/filepath"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/translog"
+	"github.com/spf13/cobra"
+)
+
+var logProofCmdFlags = struct {
+	log             string
+	treeSize        uint64
+	targetDirectory string
+	overwrite       bool
+}{}
+
+// logProofCmd represents the log proof command
+var logProofCmd = &cobra.Command{
+	Use:   "proof [licence-file]",
+	Short: "Create an inclusion proof showing a signed licence is recorded in the transparency log",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entry, err := fs.ReadFile(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		transparencyLog, err := translog.Open(logProofCmdFlags.log)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		proof, err := transparencyLog.Prove(entry, logProofCmdFlags.treeSize)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		proofBytes, err := json.MarshalIndent(proof, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if logProofCmdFlags.targetDirectory == "" {
+			logProofCmdFlags.targetDirectory = filepath.Dir(args[0])
+		}
+
+		err = fs.SaveCreateIntermediate(filepath.Join(
+			logProofCmdFlags.targetDirectory, filepath.Base(args[0])+constant.INCLUSION_PROOF_EXTENSION),
+			proofBytes, logProofCmdFlags.overwrite)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	logCmd.AddCommand(logProofCmd)
+
+	logProofCmd.Flags().StringVarP(&logProofCmdFlags.log, "log", "l", constant.TRANSPARENCY_LOG_DIRECTORY, "Transparency log directory")
+	logProofCmd.Flags().Uint64Var(&logProofCmdFlags.treeSize, "tree-size", 0, "Size of the tree head to prove against, e.g. one pinned by an auditor (default latest)")
+	logProofCmd.Flags().StringVarP(&logProofCmdFlags.targetDirectory, "target-directory", "d", "", "Directory used to save the proof. (default $licence_file_directory)")
+	logProofCmd.Flags().BoolVarP(&logProofCmdFlags.overwrite, "overwrite", "o", false, "Overwrite an existing proof")
+}
@@ -0,0 +1,190 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/eslam-allam/file-signer/internal/prompt"
+	"github.com/spf13/cobra"
+)
+
+var newCmdFlags = struct {
+	issueFlags
+	fields   map[string]*string
+	features []string
+	sign     bool
+}{fields: map[string]*string{}}
+
+// newCmd represents the new command
+var newCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Create a licence file interactively and optionally sign it",
+	Long: `Create a licence file interactively and optionally sign it.
+
+Each licence field is prompted for and validated. Flags set the defaults of
+the prompts, and are used directly when stdin is not a terminal. Date fields
+accept "today" and offsets such as "+1y", "+6m" or "+30d"; the expiry date
+offset counts from the issue date.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		interactive := prompt.IsTerminal()
+		p := prompt.New(os.Stdin, os.Stdout)
+
+		l := licence.New()
+		issued := time.Now()
+		for _, field := range licence.SchemaFields() {
+			validate := func(value string) (string, error) {
+				if field.IsDate() {
+					base := time.Now()
+					if field.Name == "expiry_date" {
+						base = issued
+					}
+					var err error
+					value, err = licence.ResolveDate(value, base)
+					if err != nil {
+						return "", err
+					}
+				}
+				return value, field.Validate(value)
+			}
+
+			var value string
+			if field.Name == "features" {
+				value = strings.Join(newCmdFlags.features, ",")
+			} else {
+				value = *newCmdFlags.fields[field.Name]
+			}
+			var err error
+			if interactive {
+				label := field.Description
+				if field.IsDate() {
+					label = strings.TrimSuffix(label, ".") + ". Accepts today or offsets such as +1y"
+				}
+				if field.Name == "features" {
+					label += ", comma separated, e.g. export,seats=5"
+				}
+				value, err = p.Ask(fmt.Sprintf("%s - %s", field.Name, label), value, validate)
+			} else {
+				value, err = validate(value)
+				if err != nil {
+					err = fmt.Errorf("stdin is not a terminal, set --%s: %w", newFieldFlag(field.Name), err)
+				}
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if field.Name == "issue_date" && value != "" {
+				issued, _ = time.Parse(time.DateOnly, value)
+			}
+			licence.SetField(&l, field.Name, value)
+		}
+
+		licencePath := filepath.Join(newCmdFlags.targetDirectory, constant.LICENCE_FILE_NAME)
+		if interactive {
+			printLicenceSummary(l)
+			save, err := p.Confirm(fmt.Sprintf("Save licence to '%s'?", licencePath), true)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !save {
+				return
+			}
+		}
+
+		licenceBytes, err := json.MarshalIndent(l, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = fs.SaveCreateIntermediate(licencePath, licenceBytes, newCmdFlags.overwrite)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		sign := newCmdFlags.sign
+		if !sign && interactive {
+			sign, err = p.Confirm("Sign it now?", false)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if sign && newCmdFlags.signer == "" {
+				newCmdFlags.privateKey, err = p.Ask("Private key", newCmdFlags.privateKey, nil)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
+		if !sign {
+			return
+		}
+
+		signedPath, err := newCmdFlags.issue(l)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Signed licence saved to '%s'", signedPath)
+	},
+}
+
+func flagName(field string) string {
+	return strings.ReplaceAll(field, "_", "-")
+}
+
+// newFieldFlag returns the new flag setting field. Features are given one
+// per --feature, like licence issue.
+func newFieldFlag(field string) string {
+	if field == "features" {
+		return "feature"
+	}
+	return flagName(field)
+}
+
+func printLicenceSummary(l licence.Licence) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "Licence key:\t%s\n", valueOr(l.LicenceKey, "(generated when signed)"))
+	fmt.Fprintf(tw, "Name:\t%s\n", l.Name)
+	fmt.Fprintf(tw, "Email:\t%s\n", l.Email)
+	fmt.Fprintf(tw, "Product:\t%s\n", l.Product)
+	fmt.Fprintf(tw, "Version:\t%s\n", l.Version)
+	fmt.Fprintf(tw, "Issuer:\t%s\n", l.Issuer)
+	fmt.Fprintf(tw, "Issued:\t%s\n", valueOr(l.IssueDate, "(today when signed)"))
+	fmt.Fprintf(tw, "Expires:\t%s\n", l.ExpiryDate)
+	fmt.Fprintf(tw, "Tier:\t%s\n", l.Tier)
+	fmt.Fprintf(tw, "Features:\t%s\n", strings.Join(l.Features, ", "))
+	fmt.Fprintln(tw)
+	tw.Flush()
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func init() {
+	licenceCmd.AddCommand(newCmd)
+
+	newCmdFlags.register(newCmd, ".", "Directory used to save the licence and signed licence")
+	for _, field := range licence.SchemaFields() {
+		if field.Name == "features" {
+			continue
+		}
+		newCmdFlags.fields[field.Name] = newCmd.Flags().String(flagName(field.Name), "", field.Description)
+	}
+	newCmd.Flags().StringArrayVar(&newCmdFlags.features, newFieldFlag("features"), nil, featureFlagUsage)
+	newCmd.Flags().BoolVar(&newCmdFlags.sign, "sign", false, "Sign the licence after saving it without asking")
+}
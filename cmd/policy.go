@@ -0,0 +1,68 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/licence"
+)
+
+// issuancePreparer loads the issuance policy at path, or issuance-policy.json
+// when path is empty and the file exists, and returns a function that applies
+// profile, fills defaults and enforces the policy on a licence.
+func issuancePreparer(path, profile string) (func(licence.Licence) (licence.Licence, error), error) {
+	return reissuancePreparer(path, profile, nil)
+}
+
+// reissuancePreparer is issuancePreparer for licences replacing previous,
+// whose term is checked with licence.IssuancePolicy.CheckRenewal. A nil
+// previous checks newly issued licences.
+func reissuancePreparer(path, profile string, previous *licence.Licence) (func(licence.Licence) (licence.Licence, error), error) {
+	if path == "" {
+		exists, typ, err := fs.Exists(constant.ISSUANCE_POLICY_FILE_NAME)
+		if err != nil {
+			return nil, err
+		}
+		if exists && typ == fs.File {
+			path = constant.ISSUANCE_POLICY_FILE_NAME
+		}
+	}
+	if path == "" {
+		if profile != "" {
+			return nil, errors.New("--profile requires an issuance policy")
+		}
+		return licence.Prepare, nil
+	}
+
+	policy, err := licence.LoadIssuancePolicy(path)
+	if err != nil {
+		return nil, err
+	}
+	return func(l licence.Licence) (licence.Licence, error) {
+		var err error
+		if profile != "" {
+			l, err = policy.ApplyProfile(l, profile)
+			if err != nil {
+				return licence.Licence{}, err
+			}
+		}
+		l, err = licence.Prepare(l)
+		if err != nil {
+			return licence.Licence{}, err
+		}
+		if previous != nil {
+			err = policy.CheckRenewal(*previous, l)
+		} else {
+			err = policy.Check(l)
+		}
+		if err != nil {
+			return licence.Licence{}, fmt.Errorf("licence violates issuance policy '%s':\n%w", path, err)
+		}
+		return l, nil
+	}, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/eslam-allam/file-signer/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var registryQueryFlags = registry.Query{}
+
+// openRegistry opens the registry given by --registry or the default one.
+func openRegistry() (*registry.Registry, error) {
+	path := licenceCmdFlags.registry
+	if path == "" {
+		var err error
+		path, err = registry.DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return registry.Open(path)
+}
+
+// recordLicence adds a signed licence saved at path to the registry.
+func recordLicence(r *registry.Registry, l licence.Licence, format licence.Format, public crypto.PublicKey, path string, signed []byte) error {
+	keyId, err := key.Fingerprint(public)
+	if err != nil {
+		return err
+	}
+	var absolute string
+	if path != constant.STANDARD_STREAM {
+		absolute, err = filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+	}
+	hash := sha256.Sum256(signed)
+	_, err = r.Add(registry.Record{
+		LicenceKey: l.LicenceKey,
+		Name:       l.Name,
+		Email:      l.Email,
+		Product:    l.Product,
+		Version:    l.Version,
+		Issuer:     l.Issuer,
+		IssueDate:  l.IssueDate,
+		ExpiryDate: l.ExpiryDate,
+		KeyId:      keyId,
+		Format:     licence.Formats[format][0],
+		File:       absolute,
+		FileHash:   hex.EncodeToString(hash[:]),
+		SignedAt:   time.Now().UTC(),
+	})
+	return err
+}
+
+// addQueryFlags registers the registry search filters on cmd.
+func addQueryFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&registryQueryFlags.Customer, "customer", "c", "", "Only licences whose name or email contains this text")
+	cmd.Flags().StringVarP(&registryQueryFlags.Product, "product", "p", "", "Only licences for this product")
+	cmd.Flags().StringVar(&registryQueryFlags.ExpiresAfter, "expires-after", "", "Only licences expiring on or after this date (yyyy-mm-dd)")
+	cmd.Flags().StringVar(&registryQueryFlags.ExpiresBefore, "expires-before", "", "Only licences expiring on or before this date (yyyy-mm-dd)")
+}
+
+func printRecords(w io.Writer, records []registry.Record) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LICENCE KEY\tNAME\tEMAIL\tPRODUCT\tVERSION\tEXPIRES\tSIGNED")
+	for _, r := range records {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.LicenceKey, r.Name, r.Email, r.Product, r.Version, r.ExpiryDate, r.SignedAt.Local().Format(time.DateTime))
+	}
+	return tw.Flush()
+}
+
+// searchRegistry runs the query given by the filter flags.
+func searchRegistry() ([]registry.Record, error) {
+	r, err := openRegistry()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return r.Search(registryQueryFlags)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/spf13/cobra"
+)
+
+// reissueFlags are shared by the commands that re-sign an existing licence.
+// The successor keeps the format of the licence it replaces.
+type reissueFlags struct {
+	issueFlags
+	publicKey string
+	product   string
+}
+
+func (f *reissueFlags) register(cmd *cobra.Command) {
+	f.registerSigning(cmd)
+	cmd.Flags().StringVar(&f.publicKey, "issuer-key", "", "Public key the existing licence must be signed by (default the signing key)")
+	cmd.Flags().StringVarP(&f.product, "product", "p", "", "Product the existing licence must be bound to, required for paseto licences")
+	cmd.Flags().StringVarP(&f.targetDirectory, "target-directory", "d", "", "Directory used to save the new licence (default replaces the input, which requires --overwrite, or stdout for -)")
+	cmd.Flags().BoolVarP(&f.overwrite, "overwrite", "o", false, "Overwrite existing files with generated files")
+}
+
+// replacementPath returns where a licence read from path and signed again is
+// written: path itself, which requires overwrite, a file of the same name in
+// targetDirectory, or stdout when path is stdin. A licence read from stdin is
+// saved in targetDirectory as stdinName.
+func replacementPath(path, targetDirectory, stdinName string, overwrite bool) (string, error) {
+	switch {
+	case targetDirectory == "" && path == constant.STANDARD_STREAM:
+		return constant.STANDARD_STREAM, nil
+	case targetDirectory == "" && !overwrite:
+		return "", fmt.Errorf("refusing to replace '%s', pass --overwrite or write the new licence elsewhere with --target-directory", path)
+	case targetDirectory == "":
+		return path, nil
+	case path == constant.STANDARD_STREAM:
+		return filepath.Join(targetDirectory, stdinName), nil
+	}
+	target := filepath.Join(targetDirectory, filepath.Base(path))
+	if filepath.Clean(target) == filepath.Clean(path) && !overwrite {
+		return "", fmt.Errorf("refusing to replace '%s', pass --overwrite or write the new licence elsewhere with --target-directory", path)
+	}
+	return target, nil
+}
+
+// reissueLicence verifies the signed licence at path, applies change to it
+// and signs the result in the same format as its successor. The successor
+// must satisfy the issuance policy like any newly issued licence, except
+// that its term is measured from the later of today and the current expiry.
+func reissueLicence(path string, flags reissueFlags, change func(licence.Licence) (licence.Licence, error)) {
+	signer, err := flags.openIssuer()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer signer.Close()
+	var issuer crypto.PublicKey = signer.private.Public()
+	if flags.publicKey != "" {
+		publicBytes, err := fs.ReadFile(flags.publicKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		issuer, err = key.ParsePublicKey(publicBytes)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	signedBytes, err := fs.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	format, err := licence.DetectFormat(signedBytes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	previous, err := licence.VerifyLicence(signedBytes, issuer, licence.VerifyOptions{Product: flags.product})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	prepare, err := reissuancePreparer(flags.policy, flags.profile, &previous)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	next, err := licence.Successor(previous, signedBytes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	next, err = change(next)
+	if err != nil {
+		log.Fatal(err)
+	}
+	next, err = prepare(next)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	target, err := replacementPath(path, flags.targetDirectory, signedLicenceFileNames[format], flags.overwrite)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = checkOutput(target, flags.overwrite)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = signer.sign(next, format, target)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReplacementPath(t *testing.T) {
+	tests := []struct {
+		name            string
+		path, directory string
+		overwrite       bool
+		want            string
+	}{
+		{"stdin to stdout", "-", "", false, "-"},
+		{"stdin to directory", "-", "out", false, filepath.Join("out", "licence.signed.jws")},
+		{"replace input", "l.jws", "", true, "l.jws"},
+		{"refuse to replace input", "l.jws", "", false, ""},
+		{"refuse to replace through directory", "in/l.jws", "in", false, ""},
+		{"other directory", "in/l.jws", "out", false, filepath.Join("out", "l.jws")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := replacementPath(test.path, test.directory, "licence.signed.jws", test.overwrite)
+			if test.want == "" {
+				if err == nil {
+					t.Fatalf("replacementPath = %q, want refusal", got)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Fatalf("replacementPath = %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/spf13/cobra"
+)
+
+var renewCmdFlags = struct {
+	reissueFlags
+	extend string
+}{}
+
+// renewCmd represents the renew command
+var renewCmd = &cobra.Command{
+	Use:   "renew [signed-licence-file]",
+	Short: "Extend the expiry date of a signed licence and re-sign it",
+	Long: `Extend the expiry date of a signed licence and re-sign it.
+
+The licence is verified with the issuer's key first. The renewed licence keeps
+its licence key, is issued today and records the SHA-256 of the previous
+signature as its predecessor.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reissueLicence(args[0], renewCmdFlags.reissueFlags, func(l licence.Licence) (licence.Licence, error) {
+			return licence.Extend(l, renewCmdFlags.extend)
+		})
+	},
+}
+
+func init() {
+	licenceCmd.AddCommand(renewCmd)
+
+	renewCmdFlags.register(renewCmd)
+	renewCmd.Flags().StringVarP(&renewCmdFlags.extend, "extend", "e", "1y",
+		"Period added to the expiry date, or to today if expired, e.g. 1y, 6m, 2w or 30d")
+}
@@ -4,15 +4,79 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"os"
+	"strings"
 
+	"github.com/eslam-allam/file-signer/internal/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+var rootCmdFlags = struct {
+	context string
+}{}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "file-signer",
 	Short: "Create, update and verify licence files",
+	Long: `Create, update and verify licence files.
+
+Commands that sign take --signer backend:argument to sign with a key held
+outside a private key file:
+
+  exec:<path>               helper speaking the JSON protocol on stdin and
+                            stdout, or exec:["<path>", "<argument>", ...]
+  vault:<key>[@<version>]   HashiCorp Vault transit key, configured through
+                            VAULT_ADDR, VAULT_TOKEN or VAULT_ROLE_ID and
+                            VAULT_SECRET_ID, and VAULT_TRANSIT_MOUNT
+  agent:SHA256:<hash>       Ed25519 key held by the ssh-agent at $SSH_AUTH_SOCK
+
+Signatures from exec helpers and Vault are checked against the backend's
+public key before they are used. Vault signs through transit/sign, but its
+signatures are deliberately checked locally rather than with transit/verify,
+so that they are known to verify with the public key verifiers are given.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(rootCmdFlags.context)
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = applyConfig(cmd, c)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// commandName is the path of cmd without the program name, e.g. "licence sign".
+func commandName(cmd *cobra.Command) string {
+	return strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+}
+
+// applyConfig sets the flags of cmd that were not given on the command line
+// from the configuration.
+func applyConfig(cmd *cobra.Command, c *config.Config) error {
+	command := commandName(cmd)
+	var errs []error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed || f.Name == "context" || f.Name == "help" {
+			return
+		}
+		setting, ok := c.Lookup(command, f.Name)
+		if !ok {
+			return
+		}
+		for _, value := range setting.Value {
+			err := cmd.Flags().Set(f.Name, value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid --%s from %s: %w", f.Name, setting.Source, err))
+			}
+		}
+	})
+	return errors.Join(errs...)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -23,3 +87,8 @@ func Execute() {
 		os.Exit(1)
 	}
 }
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&rootCmdFlags.context, "context", "",
+		"Configuration context providing flag defaults, e.g. production (default $"+config.CONTEXT_ENV+")")
+}
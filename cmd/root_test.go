@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eslam-allam/file-signer/internal/config"
+	"github.com/spf13/cobra"
+)
+
+const testLocalConfig = `
+context: staging
+defaults:
+  env: default
+  scoped: default
+  command: default
+  default: default
+  explicit: default
+commands:
+  licence sign:
+    env: command
+    scoped: command
+    command: command
+    explicit: command
+    feature: [export, seats=5]
+  licence verify:
+    default: other command
+contexts:
+  production:
+    env: context
+    scoped: context
+    explicit: context
+  staging:
+    scoped: staging
+`
+
+const testUserConfig = `
+defaults:
+  default: user
+  user: user
+`
+
+func writeTestConfig(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, config.LOCAL_FILE_NAME), []byte(testLocalConfig), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userDir := filepath.Join(t.TempDir(), "file-signer")
+	err = os.Mkdir(userDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(userDir, config.USER_FILE_NAME), []byte(testUserConfig), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv("XDG_CONFIG_HOME", filepath.Dir(userDir))
+	t.Setenv(config.CONTEXT_ENV, "")
+}
+
+// testSignCmd returns "licence sign" under a root command, with its flags
+// parsed from args.
+func testSignCmd(t *testing.T, args ...string) (*cobra.Command, map[string]*string, *[]string) {
+	t.Helper()
+	root := &cobra.Command{Use: "file-signer"}
+	licence := &cobra.Command{Use: "licence"}
+	sign := &cobra.Command{Use: "sign"}
+	root.AddCommand(licence)
+	licence.AddCommand(sign)
+
+	values := map[string]*string{}
+	for _, name := range []string{"env", "scoped", "command", "default", "explicit", "user", "unset"} {
+		values[name] = sign.Flags().String(name, "builtin", "")
+	}
+	features := sign.Flags().StringArray("feature", nil, "")
+	err := sign.ParseFlags(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sign, values, features
+}
+
+func TestApplyConfig(t *testing.T) {
+	writeTestConfig(t)
+	t.Setenv(config.EnvName("env"), "env")
+	t.Setenv(config.EnvName("explicit"), "env")
+
+	c, err := config.Load("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, values, features := testSignCmd(t, "--explicit=flag")
+	err = applyConfig(cmd, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"env":      "env",
+		"scoped":   "context",
+		"command":  "command",
+		"default":  "default",
+		"explicit": "flag",
+		"user":     "user",
+		"unset":    "builtin",
+	}
+	for name, value := range want {
+		if *values[name] != value {
+			t.Errorf("--%s = %q, want %q", name, *values[name], value)
+		}
+	}
+	if !reflect.DeepEqual(*features, []string{"export", "seats=5"}) {
+		t.Errorf("--feature = %q, want each configured value", *features)
+	}
+}
+
+func TestApplyConfigContextSelection(t *testing.T) {
+	writeTestConfig(t)
+
+	tests := []struct {
+		name    string
+		context string
+		env     string
+		want    string
+	}{
+		{"configuration file", "", "", "staging"},
+		{"environment", "", "production", "context"},
+		{"--context", "production", "staging", "context"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(config.CONTEXT_ENV, test.env)
+			c, err := config.Load(test.context)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cmd, values, _ := testSignCmd(t)
+			err = applyConfig(cmd, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *values["scoped"] != test.want {
+				t.Fatalf("--scoped = %q, want %q", *values["scoped"], test.want)
+			}
+		})
+	}
+
+	_, err := config.Load("missing")
+	if err == nil {
+		t.Fatal("Load accepted an unknown context")
+	}
+}
+
+func TestApplyConfigInvalidValue(t *testing.T) {
+	writeTestConfig(t)
+	t.Setenv(config.EnvName("jobs"), "many")
+	c, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, _, _ := testSignCmd(t)
+	cmd.Flags().Int("jobs", 1, "")
+	err = applyConfig(cmd, c)
+	if err == nil || !strings.Contains(err.Error(), "--jobs") || !strings.Contains(err.Error(), config.EnvName("jobs")) {
+		t.Fatalf("applyConfig error = %v, want one naming the flag and its source", err)
+	}
+}
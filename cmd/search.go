@@ -0,0 +1,37 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Search the licence registry by customer, product or expiry range",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		records, err := searchRegistry()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(records) == 0 {
+			log.Fatal("no matching licences")
+		}
+		err = printRecords(os.Stdout, records)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	licenceCmd.AddCommand(searchCmd)
+
+	addQueryFlags(searchCmd)
+}
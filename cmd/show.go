@@ -0,0 +1,225 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/spf13/cobra"
+)
+
+var showCmdFlags = struct {
+	publicKey string
+	signer    string
+	product   string
+	template  string
+}{}
+
+// licenceView is what licence show prints and the data given to --template.
+type licenceView struct {
+	licence.Licence
+	File   string
+	Format string
+	// KeyId is the fingerprint of the verifying key, or of the signing key
+	// named by the licence when it was not verified.
+	KeyId string
+	// Signature is "valid", "not verified" or the verification error.
+	Signature string
+	// Status is "valid", "expiring", "expired" or "not yet valid".
+	Status        string
+	DaysRemaining int
+}
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show [licence-file-or-key]",
+	Short: "Show a licence file with its status, or a licence from the licence registry",
+	Long: `Show a licence file with its status, or a licence from the licence registry.
+
+Given a signed licence file, in any format, its fields, validity status,
+signing key fingerprint and features are printed. The signature is verified
+when --public-key or --signer is given. --template formats the licence with
+Go text/template instead, e.g. '{{.Name}} {{.Status}} {{join .Features ","}}'.
+
+Given a licence key that is not a file, every recorded signing of the licence
+is shown from the licence registry.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exists, typ, err := fs.Exists(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if args[0] != constant.STANDARD_STREAM && (!exists || typ != fs.File) {
+			showRecords(args[0])
+			return
+		}
+
+		data, err := fs.ReadFile(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		view, verifyErr, err := viewLicence(args[0], data)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if showCmdFlags.template != "" {
+			tmpl, err := template.New("licence").Funcs(template.FuncMap{"join": strings.Join}).Parse(showCmdFlags.template)
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = tmpl.Execute(os.Stdout, view)
+			if err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			printLicenceView(view)
+		}
+		if verifyErr != nil {
+			os.Exit(exitCode(verifyErr))
+		}
+	},
+}
+
+// viewLicence decodes data, verifying it when a key was given, and works out
+// its status. A failed verification is returned separately so the licence can
+// still be shown.
+func viewLicence(path string, data []byte) (view licenceView, verifyErr error, err error) {
+	format, err := licence.DetectFormat(data)
+	if err != nil {
+		return licenceView{}, nil, err
+	}
+	l, keyId, err := licence.Decode(data)
+	if err != nil {
+		return licenceView{}, nil, err
+	}
+	view = licenceView{Licence: l, File: path, Format: licence.Formats[format][0], KeyId: keyId, Signature: "not verified"}
+
+	if showCmdFlags.publicKey != "" || showCmdFlags.signer != "" {
+		publicKey, err := loadPublicKey(showCmdFlags.signer, showCmdFlags.publicKey)
+		if err != nil {
+			return licenceView{}, nil, err
+		}
+		_, verifyErr = licence.VerifyLicence(data, publicKey, licence.VerifyOptions{Product: showCmdFlags.product})
+		view.Signature = "valid"
+		if verifyErr != nil {
+			view.Signature = verifyErr.Error()
+		} else {
+			// Only a key that verified the licence identifies its signer,
+			// otherwise the key named by the licence is kept.
+			view.KeyId, err = key.Fingerprint(publicKey)
+			if err != nil {
+				return licenceView{}, nil, err
+			}
+		}
+	}
+
+	view.DaysRemaining, err = licence.CheckValidity(l, time.Now())
+	switch {
+	case errors.Is(err, licence.ErrExpired):
+		view.Status = "expired"
+	case errors.Is(err, licence.ErrNotYetValid):
+		view.Status = "not yet valid"
+	case err != nil:
+		return licenceView{}, nil, err
+	case view.DaysRemaining <= EXPIRY_WARNING_DAYS:
+		view.Status = "expiring"
+	default:
+		view.Status = "valid"
+	}
+	return view, verifyErr, nil
+}
+
+func describeStatus(view licenceView) string {
+	switch view.Status {
+	case "expired":
+		return fmt.Sprintf("expired %d days ago", -view.DaysRemaining)
+	case "not yet valid":
+		return fmt.Sprintf("not valid until %s", view.IssueDate)
+	case "expiring":
+		return fmt.Sprintf("expiring in %d days", view.DaysRemaining)
+	default:
+		return fmt.Sprintf("valid, %d days remaining", view.DaysRemaining)
+	}
+}
+
+func printLicenceView(view licenceView) {
+	fmt.Printf("Licence key: %s\n", view.LicenceKey)
+	fmt.Printf("Name:        %s\n", view.Name)
+	fmt.Printf("Email:       %s\n", view.Email)
+	fmt.Printf("Product:     %s\n", view.Product)
+	fmt.Printf("Version:     %s\n", view.Version)
+	if view.Tier != "" {
+		fmt.Printf("Tier:        %s\n", view.Tier)
+	}
+	fmt.Printf("Issuer:      %s\n", view.Issuer)
+	fmt.Printf("Issued:      %s\n", view.IssueDate)
+	fmt.Printf("Expires:     %s\n", view.ExpiryDate)
+	fmt.Printf("Status:      %s\n", describeStatus(view))
+	fmt.Printf("Format:      %s\n", view.Format)
+	fmt.Printf("Key ID:      %s\n", valueOr(view.KeyId, "not named by the licence"))
+	fmt.Printf("Signature:   %s\n", view.Signature)
+	if view.Predecessor != "" {
+		fmt.Printf("Predecessor: %s\n", view.Predecessor)
+	}
+	if len(view.Features) == 0 {
+		fmt.Println("Features:    none")
+		return
+	}
+	fmt.Println("Features:")
+	for _, feature := range view.Features {
+		fmt.Printf("  - %s\n", feature)
+	}
+}
+
+// showRecords prints every recorded signing of licenceKey from the registry.
+func showRecords(licenceKey string) {
+	r, err := openRegistry()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+
+	records, err := r.Find(licenceKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i, record := range records {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Licence key: %s\n", record.LicenceKey)
+		fmt.Printf("Name:        %s\n", record.Name)
+		fmt.Printf("Email:       %s\n", record.Email)
+		fmt.Printf("Product:     %s\n", record.Product)
+		fmt.Printf("Version:     %s\n", record.Version)
+		fmt.Printf("Issuer:      %s\n", record.Issuer)
+		fmt.Printf("Issued:      %s\n", record.IssueDate)
+		fmt.Printf("Expires:     %s\n", record.ExpiryDate)
+		fmt.Printf("Key ID:      %s\n", record.KeyId)
+		fmt.Printf("Format:      %s\n", record.Format)
+		fmt.Printf("File:        %s\n", record.File)
+		fmt.Printf("SHA-256:     %s\n", record.FileHash)
+		fmt.Printf("Signed at:   %s\n", record.SignedAt.Local().Format(time.RFC3339))
+	}
+}
+
+func init() {
+	licenceCmd.AddCommand(showCmd)
+
+	showCmd.Flags().StringVarP(&showCmdFlags.publicKey, "public-key", "k", "", "Public key used to verify the licence signature (default no verification)")
+	showCmd.Flags().StringVar(&showCmdFlags.signer, "signer", "", "Signer backend whose public key verifies the licence, e.g. vault:licence-key")
+	showCmd.Flags().StringVarP(&showCmdFlags.product, "product", "p", "", "Product the licence must be bound to, required to verify paseto licences")
+	showCmd.Flags().StringVarP(&showCmdFlags.template, "template", "t", "", "Go text/template used to print the licence")
+}
@@ -10,22 +10,21 @@ import (
 
 	"github.com/eslam-allam/file-signer/internal/constant"
 	"github.com/eslam-allam/file-signer/internal/fs"
-	"github.com/eslam-allam/file-signer/internal/key"
 	"github.com/eslam-allam/file-signer/internal/licence"
 	"github.com/spf13/cobra"
 )
 
-var signCmdFlags = struct {
-	privateKey      string
-	targetDirectory string
-	overwrite       bool
-}{}
+var signCmdFlags = issueFlags{}
 
 // signCmd represents the sign command
 var signCmd = &cobra.Command{
 	Use:   "sign [file]",
 	Short: "Sign a licence file using the specified private key",
-	Args:  cobra.ExactArgs(1),
+	Long: `Sign a licence file using the specified private key.
+
+A licence file of "-" is read from stdin and, unless --out or
+--target-directory is given, the signed licence is written to stdout.`,
+	Args: cobra.ExactArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) >= 1 {
 			return []string{}, cobra.ShellCompDirectiveError
@@ -37,16 +36,6 @@ var signCmd = &cobra.Command{
 		return completions, cobra.ShellCompDirectiveDefault
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		privateKey, err := fs.ReadFile(signCmdFlags.privateKey)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		private, err := key.ParsePrivateKey(privateKey)
-		if err != nil {
-			log.Fatal(err)
-		}
-
 		message, err := fs.ReadFile(args[0])
 		if err != nil {
 			log.Fatal(err)
@@ -58,23 +47,14 @@ var signCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		signed, err := licence.SignLicence(private, l)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		signedBytes, err := json.MarshalIndent(signed, "", "  ")
-		if err != nil {
-			log.Fatal(err)
-		}
-
 		if signCmdFlags.targetDirectory == "" {
+			if args[0] == constant.STANDARD_STREAM && signCmdFlags.out == "" {
+				signCmdFlags.out = constant.STANDARD_STREAM
+			}
 			signCmdFlags.targetDirectory = filepath.Dir(args[0])
 		}
 
-		err = fs.SaveCreateIntermediate(filepath.Join(
-			signCmdFlags.targetDirectory, constant.SIGNED_LICENCE_FILE_NAME),
-			signedBytes, signCmdFlags.overwrite)
+		_, err = signCmdFlags.issue(l)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -84,7 +64,5 @@ var signCmd = &cobra.Command{
 func init() {
 	licenceCmd.AddCommand(signCmd)
 
-	signCmd.Flags().StringVarP(&signCmdFlags.privateKey, "private-key", "k", constant.PRIVATE_KEY_FILE_NAME, "Private key used to sign the licence")
-	signCmd.Flags().StringVarP(&signCmdFlags.targetDirectory, "target-directory", "d", "", "Directory used to save signed file. (default $licence_file_directory)")
-	signCmd.Flags().BoolVarP(&signCmdFlags.overwrite, "overwrite", "o", false, "Overwrite existing files with generated files")
+	signCmdFlags.register(signCmd, "", "Directory used to save signed file. (default $licence_file_directory)")
 }
@@ -0,0 +1,142 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eslam-allam/file-signer/internal/batch"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/spf13/cobra"
+)
+
+var signBatchCmdFlags = struct {
+	issueFlags
+	input        string
+	mapping      string
+	nameTemplate string
+	jobs         int
+	report       string
+}{}
+
+// signBatchCmd represents the sign-batch command
+var signBatchCmd = &cobra.Command{
+	Use:   "sign-batch",
+	Short: "Sign one licence per row of a CSV or JSON Lines file",
+	Long: `Sign one licence per row of a CSV or JSON Lines file.
+
+Columns are matched to licence fields by name (licence_key, name, email,
+product, version, issuer, issue_date, expiry_date, tier and features as a
+comma separated list). A mapping file can rename columns and provide defaults:
+
+  {"fields": {"name": "Customer", "expiry_date": "Renewal"}, "defaults": {"issuer": "Acme"}}
+
+Every row is validated before anything is signed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var mapping batch.Mapping
+		if signBatchCmdFlags.mapping != "" {
+			var err error
+			mapping, err = batch.LoadMapping(signBatchCmdFlags.mapping)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		rows, err := batch.Read(signBatchCmdFlags.input, mapping)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		nameTemplate := signBatchCmdFlags.nameTemplate
+		if nameTemplate == "" {
+			nameTemplate = "{{.LicenceKey}}" + strings.TrimPrefix(signedLicenceFileNames[signBatchCmdFlags.format], "licence")
+		}
+		prepare, err := issuancePreparer(signBatchCmdFlags.policy, signBatchCmdFlags.profile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		valid, err := batch.Validate(rows, prepare, nameTemplate, signBatchCmdFlags.targetDirectory, signBatchCmdFlags.overwrite)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !valid {
+			errors := batch.Errors(rows)
+			finishBatch(errors)
+			log.Fatalf("%d of %d rows are invalid, nothing was signed", len(errors), len(rows))
+		}
+
+		i, err := signBatchCmdFlags.openIssuer()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer i.Close()
+		results := batch.Run(rows, signBatchCmdFlags.jobs, func(row batch.Row) error {
+			_, err := i.sign(row.Licence, signBatchCmdFlags.format, row.File)
+			return err
+		})
+
+		failed := finishBatch(results)
+		if failed != 0 {
+			log.Fatalf("%d of %d licences failed", failed, len(results))
+		}
+		log.Printf("Signed %d licences", len(results))
+	},
+}
+
+// finishBatch prints the summary, writes the --report file and returns the
+// number of failed rows.
+func finishBatch(results []batch.Result) int {
+	failed := printBatchSummary(os.Stdout, results)
+	if signBatchCmdFlags.report != "" {
+		report, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = fs.SaveCreateIntermediate(signBatchCmdFlags.report, report, true)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	return failed
+}
+
+func printBatchSummary(w io.Writer, results []batch.Result) int {
+	failed := 0
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LINE\tSTATUS\tLICENCE KEY\tDETAIL")
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+			fmt.Fprintf(tw, "%d\tfailed\t%s\t%s\n", result.Line, result.LicenceKey, result.Error)
+		} else {
+			fmt.Fprintf(tw, "%d\tsigned\t%s\t%s\n", result.Line, result.LicenceKey, result.File)
+		}
+	}
+	tw.Flush()
+	return failed
+}
+
+func init() {
+	licenceCmd.AddCommand(signBatchCmd)
+
+	signBatchCmd.Flags().StringVarP(&signBatchCmdFlags.input, "input", "i", "", "CSV or JSON Lines file with one licence per row")
+	signBatchCmd.Flags().StringVarP(&signBatchCmdFlags.mapping, "mapping", "m", "", "JSON file mapping input columns to licence fields")
+	signBatchCmdFlags.registerSigning(signBatchCmd)
+	signBatchCmdFlags.registerFormat(signBatchCmd)
+	signBatchCmd.Flags().StringVarP(&signBatchCmdFlags.targetDirectory, "target-directory", "d", ".", "Directory used to save signed files")
+	signBatchCmd.Flags().BoolVarP(&signBatchCmdFlags.overwrite, "overwrite", "o", false, "Overwrite existing files with generated files")
+	signBatchCmd.Flags().StringVarP(&signBatchCmdFlags.nameTemplate, "name-template", "t", "",
+		"Go template for each signed file name, given the licence fields, e.g. {{.Product}}-{{.Name}}.json (default {{.LicenceKey}}.signed.<format>)")
+	signBatchCmd.Flags().IntVarP(&signBatchCmdFlags.jobs, "jobs", "j", runtime.NumCPU(), "Number of licences signed concurrently")
+	signBatchCmd.Flags().StringVarP(&signBatchCmdFlags.report, "report", "r", "", "File to write a JSON report of every row to")
+	signBatchCmd.MarkFlagRequired("input")
+}
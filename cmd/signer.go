@@ -0,0 +1,38 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto"
+
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/signer"
+)
+
+// loadSigner opens the signer backend given by spec, falling back to the
+// private key file when no backend was requested.
+func loadSigner(spec, privateKey string) (crypto.Signer, error) {
+	if spec == "" {
+		spec = signer.FILE_BACKEND + ":" + privateKey
+	}
+	return signer.Open(spec)
+}
+
+// loadPublicKey reads the public key of the signer backend given by spec,
+// falling back to the public key file when no backend was requested.
+func loadPublicKey(spec, publicKey string) (crypto.PublicKey, error) {
+	if spec != "" {
+		s, err := signer.Open(spec)
+		if err != nil {
+			return nil, err
+		}
+		return s.Public(), nil
+	}
+	publicBytes, err := fs.ReadFile(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return key.ParsePublicKey(publicBytes)
+}
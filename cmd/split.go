@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/spf13/cobra"
+)
+
+var splitKeyFlags = struct {
+	privateKey      string
+	targetDirectory string
+	shares          int
+	threshold       int
+	overwrite       bool
+}{}
+
+// splitCmd represents the split command
+var splitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Split a private key into shares, a threshold of which can rebuild it",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		privateKey, err := fs.ReadFile(splitKeyFlags.privateKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		private, err := key.ParsePrivateKey(privateKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		shares, err := key.SplitPrivateKey(private, splitKeyFlags.shares, splitKeyFlags.threshold)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for i, share := range shares {
+			err = fs.SaveCreateIntermediate(
+				filepath.Join(splitKeyFlags.targetDirectory, fmt.Sprintf(constant.KEY_SHARE_FILE_NAME, i+1)),
+				share, splitKeyFlags.overwrite)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	},
+}
+
+func init() {
+	keyCmd.AddCommand(splitCmd)
+
+	splitCmd.Flags().StringVarP(&splitKeyFlags.privateKey, "private-key", "k", constant.PRIVATE_KEY_FILE_NAME, "Private key to split")
+	splitCmd.Flags().StringVarP(&splitKeyFlags.targetDirectory, "target-directory", "d", ".", "Directory used to save the shares")
+	splitCmd.Flags().IntVarP(&splitKeyFlags.shares, "shares", "n", 5, "Number of shares to create")
+	splitCmd.Flags().IntVarP(&splitKeyFlags.threshold, "threshold", "t", 3, "Number of shares required to rebuild the key")
+	splitCmd.Flags().BoolVarP(&splitKeyFlags.overwrite, "overwrite", "o", false, "Overwrite existing share files")
+}
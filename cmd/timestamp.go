@@ -0,0 +1,28 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/eslam-allam/file-signer/internal/timestamp"
+)
+
+// loadTimestampOptions builds timestamp verification options from the
+// --tsa-roots and --revoked-at flags. Empty values keep the defaults.
+func loadTimestampOptions(roots, revokedAt string) (timestamp.VerifyOptions, error) {
+	var options timestamp.VerifyOptions
+	var err error
+	if roots != "" {
+		options.Roots, err = timestamp.LoadRoots(roots)
+		if err != nil {
+			return timestamp.VerifyOptions{}, err
+		}
+	}
+	if revokedAt != "" {
+		options.RevokedAt, err = timestamp.ParseTime(revokedAt)
+		if err != nil {
+			return timestamp.VerifyOptions{}, err
+		}
+	}
+	return options, nil
+}
@@ -6,55 +6,216 @@ package cmd
 import (
 	"encoding/json"
 	"log"
+	"runtime"
+	"time"
 
 	"github.com/eslam-allam/file-signer/internal/constant"
 	"github.com/eslam-allam/file-signer/internal/fs"
 	"github.com/eslam-allam/file-signer/internal/key"
 	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/eslam-allam/file-signer/internal/translog"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
 )
 
 var verifyCmdFlags = struct {
-	publicKey string
+	publicKeys []string
+	signer     string
+	product    string
+	policy     string
+	tsaRoots   string
+	revokedAt  string
+	proof      string
+	treeHead   string
+	logKey     string
+	output     outputFormat
+	recursive  bool
+	include    []string
+	exclude    []string
+	jobs       int
 }{}
 
 // verifyCmd represents the verify command
 var verifyCmd = &cobra.Command{
-	Use:   "verify [licence-file]",
-	Args:  cobra.ExactArgs(1),
+	Use:   "verify [licence-file-or-directory...]",
+	Args:  cobra.MinimumNArgs(1),
 	Short: "Verify a licence file using public key",
+	Long: `Verify a licence file using public key.
+
+The signature is checked, then the licence must be within its issue and
+expiry dates. With --output json a structured result is printed to stdout.
+Several licences, or directories of licences, are verified in parallel and
+reported grouped as valid, expiring, expired and invalid. When several public
+keys are given each licence may be signed by any of them.
+
+--proof checks a transparency log inclusion proof against the tree head given
+with --tree-head, which should be one the verifier pinned earlier. Without
+--tree-head the proof is checked against the tree head it carries, so only
+that head's signature by the log key is verified, not that the log showed the
+same tree to everyone else.
+
+Failures exit with a code per failure class, or 1 when verifying several
+licences fails for different reasons:
+
+  2  invalid signature or inclusion proof
+  3  licence expired
+  4  licence not yet valid
+  5  signing key revoked before the licence was timestamped
+  6  malformed licence or input
+  7  key error, such as an unreadable key or a licence signed by another key`,
 	Run: func(cmd *cobra.Command, args []string) {
-		signedLicenceBytes, err := fs.ReadFile(args[0])
+		output := verifyCmdFlags.output
+		paths, batch, err := collectLicences(args, verifyCmdFlags.recursive, verifyCmdFlags.include, verifyCmdFlags.exclude)
 		if err != nil {
 			log.Fatal(err)
 		}
-		var signedLicence licence.SignedLicence
-		err = json.Unmarshal(signedLicenceBytes, &signedLicence)
+		if batch && verifyCmdFlags.proof != "" {
+			log.Fatal("--proof only applies to a single licence")
+		}
+
+		verifier, err := loadVerifier()
 		if err != nil {
-			log.Fatal(err)
+			if batch {
+				log.Fatal(err)
+			}
+			printResult(newResult(args[0]).fail(err, EXIT_KEY_ERROR), output)
 		}
 
-		publicBytes, err := fs.ReadFile(verifyCmdFlags.publicKey)
+		if batch {
+			printReport(verifyLicences(verifier, paths, verifyCmdFlags.jobs), output)
+			return
+		}
+
+		result := newResult(args[0])
+		signedLicenceBytes, err := fs.ReadFile(args[0])
 		if err != nil {
-			log.Fatal(err)
+			printResult(result.fail(err, EXIT_MALFORMED_INPUT), output)
 		}
 
-		publicKey, err := key.ParsePublicKey(publicBytes)
+		if verifyCmdFlags.proof != "" {
+			err = verifyInclusion(signedLicenceBytes)
+			if err != nil {
+				printResult(result.fail(err, EXIT_INVALID_SIGNATURE), output)
+			}
+		}
+
+		printResult(verifier.verify(args[0], signedLicenceBytes), output)
+	},
+}
+
+// loadVerifier loads the trust policy or the public keys and the timestamp
+// options the licences are verified with.
+func loadVerifier() (licenceVerifier, error) {
+	verifier := licenceVerifier{now: time.Now()}
+	if verifyCmdFlags.policy != "" {
+		policy, err := licence.LoadTrustPolicy(verifyCmdFlags.policy)
 		if err != nil {
-			log.Fatal(err)
+			return licenceVerifier{}, err
+		}
+		verifier.policy = &policy
+	} else {
+		publicKeys := verifyCmdFlags.publicKeys
+		if verifyCmdFlags.signer != "" {
+			publicKeys = []string{""}
 		}
+		for _, publicKeyPath := range publicKeys {
+			publicKey, err := loadPublicKey(verifyCmdFlags.signer, publicKeyPath)
+			if err != nil {
+				return licenceVerifier{}, err
+			}
+			keyId, err := key.Fingerprint(publicKey)
+			if err != nil {
+				return licenceVerifier{}, err
+			}
+			verifier.keys = append(verifier.keys, verificationKey{publicKey: publicKey, keyId: keyId})
+		}
+	}
+
+	timestampOptions, err := loadTimestampOptions(verifyCmdFlags.tsaRoots, verifyCmdFlags.revokedAt)
+	if err != nil {
+		return licenceVerifier{}, err
+	}
+	verifier.options = licence.VerifyOptions{
+		Product:   verifyCmdFlags.product,
+		Timestamp: timestampOptions,
+	}
+	return verifier, nil
+}
 
-		err = licence.VerifyLicenceSignature(signedLicence, publicKey)
+// verifyInclusion checks the --proof inclusion proof for the licence
+// against the trusted --tree-head. Without one the tree head embedded in the
+// proof is used, which the proof supplies itself, so only its signature is
+// meaningful.
+func verifyInclusion(entry []byte) error {
+	proofBytes, err := fs.ReadFile(verifyCmdFlags.proof)
+	if err != nil {
+		return err
+	}
+	var proof translog.InclusionProof
+	err = json.Unmarshal(proofBytes, &proof)
+	if err != nil {
+		return err
+	}
+
+	head := proof.TreeHead
+	if verifyCmdFlags.treeHead != "" {
+		headBytes, err := fs.ReadFile(verifyCmdFlags.treeHead)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-		log.Print("Signature valid")
-	},
+		err = json.Unmarshal(headBytes, &head)
+		if err != nil {
+			return err
+		}
+	}
+
+	logKey := verifyCmdFlags.logKey
+	if logKey == "" && len(verifyCmdFlags.publicKeys) != 0 {
+		logKey = verifyCmdFlags.publicKeys[0]
+	}
+	publicKey, err := loadPublicKey(verifyCmdFlags.signer, logKey)
+	if err != nil {
+		return err
+	}
+	return translog.VerifyInclusion(entry, proof, head, publicKey)
 }
 
 func init() {
 	licenceCmd.AddCommand(verifyCmd)
 
-	verifyCmd.Flags().StringVarP(&verifyCmdFlags.publicKey,
-		"public-key", "k", constant.PUBLIC_KEY_FILE_NAME, "Public key used for verifying licence signature")
+	oe := enumflag.New(
+		&verifyCmdFlags.output,
+		"output",
+		outputFormats,
+		enumflag.EnumCaseInsensitive,
+	)
+	oe.RegisterCompletion(verifyCmd, "output", outputFormatDescription)
+
+	verifyCmd.Flags().StringArrayVarP(&verifyCmdFlags.publicKeys,
+		"public-key", "k", []string{constant.PUBLIC_KEY_FILE_NAME}, "Public key used for verifying licence signature (repeatable)")
+	verifyCmd.Flags().StringVar(&verifyCmdFlags.signer,
+		"signer", "", "Signer backend whose public key is used instead of the public key file, e.g. vault:licence-key")
+	verifyCmd.Flags().StringVarP(&verifyCmdFlags.product,
+		"product", "p", "", "Product the licence must be bound to, required for paseto licences")
+	verifyCmd.Flags().StringVar(&verifyCmdFlags.policy,
+		"policy", "", "Trust policy requiring k of n keys to have signed a JSON licence, used instead of a single public key")
+	verifyCmd.Flags().StringVar(&verifyCmdFlags.tsaRoots,
+		"tsa-roots", "", "PEM bundle of trusted time-stamping authority roots (default system roots)")
+	verifyCmd.Flags().StringVar(&verifyCmdFlags.revokedAt,
+		"revoked-at", "", "Time the signing key was revoked (yyyy-mm-dd or RFC 3339). Only licences timestamped before it are accepted")
+	verifyCmd.Flags().StringVar(&verifyCmdFlags.proof,
+		"proof", "", "Require a valid transparency log inclusion proof from this file")
+	verifyCmd.Flags().StringVar(&verifyCmdFlags.treeHead,
+		"tree-head", "", "Trusted tree head the inclusion proof must match (default the tree head in the proof, whose signature is then the only check)")
+	verifyCmd.Flags().StringVar(&verifyCmdFlags.logKey,
+		"log-key", "", "Public key that signs the transparency log tree heads (default the licence public key)")
+	verifyCmd.Flags().Var(oe, "output", "Format of the verification result")
+	verifyCmd.Flags().BoolVarP(&verifyCmdFlags.recursive,
+		"recursive", "r", false, "Verify licences in subdirectories of the given directories")
+	verifyCmd.Flags().StringArrayVar(&verifyCmdFlags.include,
+		"include", defaultLicencePatterns, "Glob matched against file names found in directories (repeatable)")
+	verifyCmd.Flags().StringArrayVar(&verifyCmdFlags.exclude,
+		"exclude", []string{"*" + constant.INCLUSION_PROOF_EXTENSION, constant.SCHEMA_FILE_NAME}, "Glob of file names skipped in directories (repeatable)")
+	verifyCmd.Flags().IntVarP(&verifyCmdFlags.jobs,
+		"jobs", "j", runtime.NumCPU(), "Number of licences verified in parallel")
 }
@@ -0,0 +1,202 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/eslam-allam/file-signer/internal/constant"
+	"github.com/eslam-allam/file-signer/internal/fs"
+)
+
+// Report groups of a batch verification, in the order they are printed.
+const (
+	GROUP_VALID    = "valid"
+	GROUP_EXPIRING = "expiring"
+	GROUP_EXPIRED  = "expired"
+	GROUP_INVALID  = "invalid"
+)
+
+var reportGroups = []string{GROUP_VALID, GROUP_EXPIRING, GROUP_EXPIRED, GROUP_INVALID}
+
+// defaultLicencePatterns match the names signed licences are saved under,
+// such as licence.signed.json, so that keys, unsigned licences, key shares,
+// policies and log files next to them are not picked up.
+var defaultLicencePatterns = []string{
+	"*" + strings.TrimPrefix(constant.SIGNED_LICENCE_FILE_NAME, "licence"),
+	"*" + strings.TrimPrefix(constant.SIGNED_LICENCE_PEM_FILE_NAME, "licence"),
+	"*" + strings.TrimPrefix(constant.SIGNED_LICENCE_JWS_FILE_NAME, "licence"),
+	"*" + strings.TrimPrefix(constant.SIGNED_LICENCE_JWS_JSON_FILE_NAME, "licence"),
+	"*" + strings.TrimPrefix(constant.SIGNED_LICENCE_COSE_FILE_NAME, "licence"),
+	"*" + strings.TrimPrefix(constant.SIGNED_LICENCE_PASETO_FILE_NAME, "licence"),
+}
+
+type verifyReport struct {
+	Summary map[string]int            `json:"summary"`
+	Groups  map[string][]verifyResult `json:"groups"`
+}
+
+// collectLicences expands directories in paths into the licence files they
+// contain. batch reports whether more than a single file was requested.
+func collectLicences(paths []string, recursive bool, include, exclude []string) (licences []string, batch bool, err error) {
+	for _, pattern := range append(include, exclude...) {
+		_, err := filepath.Match(pattern, "")
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+	}
+
+	batch = len(paths) > 1
+	for _, path := range paths {
+		exists, typ, err := fs.Exists(path)
+		if err != nil {
+			return nil, false, err
+		}
+		if path == constant.STANDARD_STREAM || !exists || typ != fs.Directory {
+			licences = append(licences, path)
+			continue
+		}
+
+		batch = true
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if file != path && !recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if matchesAny(include, info.Name()) && !matchesAny(exclude, info.Name()) {
+				licences = append(licences, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	return licences, batch, nil
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyLicences verifies paths using jobs workers. Results keep the order
+// of paths.
+func verifyLicences(verifier licenceVerifier, paths []string, jobs int) []verifyResult {
+	results := make([]verifyResult, len(paths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < max(jobs, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				data, err := fs.ReadFile(paths[index])
+				if err != nil {
+					results[index] = newResult(paths[index]).fail(err, EXIT_MALFORMED_INPUT)
+					continue
+				}
+				results[index] = verifier.verify(paths[index], data)
+			}
+		}()
+	}
+	for index := range paths {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+func reportGroup(result verifyResult) string {
+	switch {
+	case result.Valid && len(result.Warnings) != 0:
+		return GROUP_EXPIRING
+	case result.Valid:
+		return GROUP_VALID
+	case result.ExitCode == EXIT_EXPIRED:
+		return GROUP_EXPIRED
+	default:
+		return GROUP_INVALID
+	}
+}
+
+// batchExitCode is the exit code shared by all failures, or 1 when they
+// failed for different reasons.
+func batchExitCode(results []verifyResult) int {
+	code := 0
+	for _, result := range results {
+		switch {
+		case result.ExitCode == 0:
+		case code == 0:
+			code = result.ExitCode
+		case code != result.ExitCode:
+			return 1
+		}
+	}
+	return code
+}
+
+// printReport prints results grouped by outcome and exits non-zero when any
+// licence failed verification.
+func printReport(results []verifyResult, output outputFormat) {
+	report := verifyReport{Summary: map[string]int{"total": len(results)}, Groups: map[string][]verifyResult{}}
+	for _, group := range reportGroups {
+		report.Summary[group] = 0
+		report.Groups[group] = []verifyResult{}
+	}
+	for _, result := range results {
+		group := reportGroup(result)
+		report.Summary[group]++
+		report.Groups[group] = append(report.Groups[group], result)
+	}
+	for _, group := range report.Groups {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].File < group[j].File })
+	}
+
+	if output == OUTPUT_JSON {
+		reportBytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(reportBytes))
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "STATUS\tFILE\tLICENCE KEY\tPRODUCT\tEXPIRES\tDETAIL")
+		for _, group := range reportGroups {
+			for _, result := range report.Groups[group] {
+				detail := result.Error
+				if detail == "" {
+					detail = strings.Join(result.Warnings, "; ")
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+					group, result.File, result.LicenceKey, result.Product, result.ExpiryDate, detail)
+			}
+		}
+		tw.Flush()
+		fmt.Printf("\n%d licences: %d valid, %d expiring, %d expired, %d invalid\n", len(results),
+			report.Summary[GROUP_VALID], report.Summary[GROUP_EXPIRING], report.Summary[GROUP_EXPIRED], report.Summary[GROUP_INVALID])
+	}
+
+	if code := batchExitCode(results); code != 0 {
+		os.Exit(code)
+	}
+}
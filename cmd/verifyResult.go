@@ -0,0 +1,219 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/eslam-allam/file-signer/internal/sign"
+	"github.com/eslam-allam/file-signer/internal/timestamp"
+)
+
+// Exit codes of the verification commands, one per failure class.
+const (
+	EXIT_INVALID_SIGNATURE = 2
+	EXIT_EXPIRED           = 3
+	EXIT_NOT_YET_VALID     = 4
+	EXIT_REVOKED           = 5
+	EXIT_MALFORMED_INPUT   = 6
+	EXIT_KEY_ERROR         = 7
+)
+
+// Licences expiring within this many days are reported with a warning.
+const EXPIRY_WARNING_DAYS = 30
+
+type outputFormat int
+
+const (
+	OUTPUT_TEXT outputFormat = iota
+	OUTPUT_JSON
+)
+
+var outputFormats = map[outputFormat][]string{
+	OUTPUT_TEXT: {"text"},
+	OUTPUT_JSON: {"json"},
+}
+
+var outputFormatDescription = map[outputFormat]string{
+	OUTPUT_TEXT: "human readable messages on stderr.",
+	OUTPUT_JSON: "structured verification result on stdout.",
+}
+
+// verifyResult is the outcome of verifying one licence.
+type verifyResult struct {
+	File          string   `json:"file"`
+	Valid         bool     `json:"valid"`
+	Signature     string   `json:"signature"`
+	KeyId         string   `json:"key_id,omitempty"`
+	Format        string   `json:"format,omitempty"`
+	LicenceKey    string   `json:"licence_key,omitempty"`
+	Product       string   `json:"product,omitempty"`
+	ExpiryDate    string   `json:"expiry_date,omitempty"`
+	Expiry        string   `json:"expiry"`
+	DaysRemaining *int     `json:"days_remaining,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+	Error         string   `json:"error,omitempty"`
+	ExitCode      int      `json:"exit_code"`
+}
+
+type verificationKey struct {
+	publicKey crypto.PublicKey
+	keyId     string
+}
+
+// licenceVerifier verifies licences against either public keys or a trust
+// policy and checks that they are currently valid.
+type licenceVerifier struct {
+	keys    []verificationKey
+	policy  *licence.TrustPolicy
+	options licence.VerifyOptions
+	now     time.Time
+}
+
+func newResult(path string) verifyResult {
+	return verifyResult{File: path, Signature: "unverified", Expiry: "unknown"}
+}
+
+// fail records err in the result with the exit code of its failure class,
+// or with code when it is not zero.
+func (r verifyResult) fail(err error, code int) verifyResult {
+	if code == 0 {
+		code = exitCode(err)
+	}
+	r.Valid = false
+	r.Error = err.Error()
+	r.ExitCode = code
+	return r
+}
+
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, timestamp.ErrRevoked):
+		return EXIT_REVOKED
+	case errors.Is(err, licence.ErrExpired):
+		return EXIT_EXPIRED
+	case errors.Is(err, licence.ErrNotYetValid):
+		return EXIT_NOT_YET_VALID
+	case errors.Is(err, sign.ErrInvalidSignature):
+		return EXIT_INVALID_SIGNATURE
+	case errors.Is(err, licence.ErrKeyMismatch), errors.Is(err, sign.ErrInvalidPublicKey):
+		return EXIT_KEY_ERROR
+	default:
+		return EXIT_MALFORMED_INPUT
+	}
+}
+
+func (v licenceVerifier) verify(path string, data []byte) verifyResult {
+	result := newResult(path)
+	format, err := licence.DetectFormat(data)
+	if err != nil {
+		return result.fail(err, EXIT_MALFORMED_INPUT)
+	}
+	result.Format = licence.Formats[format][0]
+
+	var l licence.Licence
+	if v.policy != nil {
+		var signed licence.SignedLicence
+		if format != licence.JSON {
+			err = errors.New("trust policies only apply to json licences")
+		} else if err = json.Unmarshal(data, &signed); err == nil {
+			err = licence.VerifyThreshold(signed, *v.policy)
+			l = signed.Licence
+		}
+	} else {
+		l, result.KeyId, err = v.verifySignature(data)
+	}
+	switch {
+	case errors.Is(err, sign.ErrInvalidSignature):
+		result.Signature = "invalid"
+	case errors.Is(err, timestamp.ErrRevoked):
+		result.Signature = "revoked"
+	case errors.Is(err, licence.ErrExpired):
+		result.Signature = "valid"
+		result.Expiry = "expired"
+	}
+	if err != nil {
+		return result.fail(err, 0)
+	}
+
+	result.Signature = "valid"
+	result.LicenceKey = l.LicenceKey
+	result.Product = l.Product
+	result.ExpiryDate = l.ExpiryDate
+	days, err := licence.CheckValidity(l, v.now)
+	switch {
+	case errors.Is(err, licence.ErrExpired):
+		result.Expiry = "expired"
+	case errors.Is(err, licence.ErrNotYetValid):
+		result.Expiry = "not-yet-valid"
+	case err == nil:
+		result.Expiry = "valid"
+	}
+	if result.Expiry != "unknown" {
+		result.DaysRemaining = &days
+	}
+	if err != nil {
+		return result.fail(err, 0)
+	}
+
+	if days <= EXPIRY_WARNING_DAYS {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("licence expires in %d days", days))
+	}
+	result.Valid = true
+	return result
+}
+
+// verifySignature tries each key in turn and returns the licence and the id
+// of the key that signed it. When no key matches, the error of the first key
+// is returned.
+func (v licenceVerifier) verifySignature(data []byte) (licence.Licence, string, error) {
+	var firstErr error
+	for _, k := range v.keys {
+		l, err := licence.VerifyLicence(data, k.publicKey, v.options)
+		wrongKey := errors.Is(err, sign.ErrInvalidSignature) || errors.Is(err, licence.ErrKeyMismatch) ||
+			errors.Is(err, sign.ErrInvalidPublicKey)
+		if !wrongKey {
+			return l, k.keyId, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(v.keys) == 1 {
+		return licence.Licence{}, v.keys[0].keyId, firstErr
+	}
+	return licence.Licence{}, "", firstErr
+}
+
+// printResult reports result in the requested output format and exits with
+// its exit code when verification failed.
+func printResult(result verifyResult, output outputFormat) {
+	if output == OUTPUT_JSON {
+		resultBytes, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(resultBytes))
+	} else if result.Valid {
+		log.Print("Signature valid")
+		log.Printf("Licence expires on %s (%d days remaining)", result.ExpiryDate, *result.DaysRemaining)
+		for _, warning := range result.Warnings {
+			log.Printf("Warning: %s", warning)
+		}
+	} else {
+		log.Print(result.Error)
+	}
+	if result.ExitCode != 0 {
+		os.Exit(result.ExitCode)
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/eslam-allam/file-signer/internal/sign"
+	"github.com/eslam-allam/file-signer/internal/timestamp"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{sign.ErrInvalidSignature, EXIT_INVALID_SIGNATURE},
+		{fmt.Errorf("licence: %w", sign.ErrInvalidSignature), EXIT_INVALID_SIGNATURE},
+		{fmt.Errorf("%w on 2024-01-01", licence.ErrExpired), EXIT_EXPIRED},
+		{fmt.Errorf("%w: valid from 2999-01-01", licence.ErrNotYetValid), EXIT_NOT_YET_VALID},
+		{fmt.Errorf("signing certificate: %w", timestamp.ErrRevoked), EXIT_REVOKED},
+		{errors.New("unexpected end of JSON input"), EXIT_MALFORMED_INPUT},
+		{licence.ErrKeyMismatch, EXIT_KEY_ERROR},
+		{fmt.Errorf("key: %w", sign.ErrInvalidPublicKey), EXIT_KEY_ERROR},
+		// A revoked timestamp is reported as revoked even when the signature
+		// it covers is also invalid.
+		{errors.Join(sign.ErrInvalidSignature, timestamp.ErrRevoked), EXIT_REVOKED},
+	}
+	for _, test := range tests {
+		if got := exitCode(test.err); got != test.want {
+			t.Errorf("exitCode(%v) = %d, want %d", test.err, got, test.want)
+		}
+	}
+}
+
+func TestVerifyExitCode(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	signed := func(issueDate, expiryDate string) []byte {
+		t.Helper()
+		data, err := licence.SignLicenceAs(private, licence.Licence{
+			LicenceKey: "5b7c2a3e-9f14-4d2a-8c1e-2f6b9a0d4e71",
+			Name:       "Jane Doe",
+			Email:      "jane@example.com",
+			Product:    "editor",
+			Version:    "1",
+			Issuer:     "acme",
+			IssueDate:  issueDate,
+			ExpiryDate: expiryDate,
+		}, licence.JSON, licence.SignOptions{OmitKeyId: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	valid := signed("2024-01-01", "2025-01-01")
+	tampered := bytes.Replace(valid, []byte("Jane Doe"), []byte("Joan Doe"), 1)
+
+	tests := []struct {
+		name string
+		key  ed25519.PublicKey
+		data []byte
+		want int
+	}{
+		{"valid", public, valid, 0},
+		{"expired", public, signed("2023-01-01", "2024-01-01"), EXIT_EXPIRED},
+		{"not yet valid", public, signed("2024-07-01", "2025-07-01"), EXIT_NOT_YET_VALID},
+		{"other key", otherPublic, valid, EXIT_INVALID_SIGNATURE},
+		{"tampered", public, tampered, EXIT_INVALID_SIGNATURE},
+		{"not a licence", public, []byte("not a licence"), EXIT_MALFORMED_INPUT},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier := licenceVerifier{keys: []verificationKey{{publicKey: test.key}}, now: now}
+			result := verifier.verify("licence.json", test.data)
+			if result.ExitCode != test.want || result.Valid != (test.want == 0) {
+				t.Fatalf("verify = %+v, want exit code %d", result, test.want)
+			}
+		})
+	}
+}
+
+func TestBatchExitCode(t *testing.T) {
+	results := func(codes ...int) []verifyResult {
+		var results []verifyResult
+		for _, code := range codes {
+			results = append(results, verifyResult{ExitCode: code})
+		}
+		return results
+	}
+	tests := []struct {
+		name    string
+		results []verifyResult
+		want    int
+	}{
+		{"empty", nil, 0},
+		{"all valid", results(0, 0), 0},
+		{"one failure", results(0, EXIT_EXPIRED, 0), EXIT_EXPIRED},
+		{"same failure", results(EXIT_REVOKED, 0, EXIT_REVOKED), EXIT_REVOKED},
+		{"mixed failures", results(EXIT_EXPIRED, 0, EXIT_INVALID_SIGNATURE), 1},
+		{"mixed after repeated failure", results(EXIT_KEY_ERROR, EXIT_KEY_ERROR, EXIT_MALFORMED_INPUT), 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := batchExitCode(test.results); got != test.want {
+				t.Fatalf("batchExitCode = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
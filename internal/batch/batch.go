@@ -0,0 +1,283 @@
+// Package batch reads licences in bulk from CSV or JSON Lines files and
+// issues them concurrently.
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/licence"
+)
+
+// Mapping describes how input columns become licence fields. Fields maps a
+// licence field (e.g. expiry_date) to the column holding it; unmapped fields
+// are read from a column of the same name. Defaults supplies values for
+// fields that are empty or missing in a row.
+type Mapping struct {
+	Fields   map[string]string `json:"fields"`
+	Defaults map[string]string `json:"defaults"`
+}
+
+type Row struct {
+	// Line is where the row starts in the input file.
+	Line    int
+	Licence licence.Licence
+	// File is where the signed licence will be written.
+	File string
+	Err  error
+}
+
+type Result struct {
+	Line       int    `json:"line"`
+	LicenceKey string `json:"licence_key,omitempty"`
+	File       string `json:"file,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func LoadMapping(path string) (Mapping, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return Mapping{}, err
+	}
+	var mapping Mapping
+	err = json.Unmarshal(data, &mapping)
+	if err != nil {
+		return Mapping{}, fmt.Errorf("invalid mapping '%s': %w", path, err)
+	}
+	for field := range mapping.Fields {
+		if !slices.Contains(licence.Fields, field) {
+			return Mapping{}, fmt.Errorf("mapping '%s' names unknown licence field '%s'", path, field)
+		}
+	}
+	for field := range mapping.Defaults {
+		if !slices.Contains(licence.Fields, field) {
+			return Mapping{}, fmt.Errorf("mapping '%s' names unknown licence field '%s'", path, field)
+		}
+	}
+	return mapping, nil
+}
+
+func (m Mapping) column(field string) string {
+	if column, ok := m.Fields[field]; ok {
+		return column
+	}
+	return field
+}
+
+func (m Mapping) licence(values map[string]string) licence.Licence {
+	var l licence.Licence
+	for _, field := range licence.Fields {
+		value := strings.TrimSpace(values[m.column(field)])
+		if value == "" {
+			value = m.Defaults[field]
+		}
+		licence.SetField(&l, field, value)
+	}
+	return l
+}
+
+func readCSV(r io.Reader, mapping Mapping) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		values := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(record) {
+				values[strings.TrimSpace(column)] = record[i]
+			}
+		}
+		rows = append(rows, Row{Line: line, Licence: mapping.licence(values)})
+	}
+	return rows, nil
+}
+
+func readJSONLines(r io.Reader, mapping Mapping) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	var rows []Row
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		row := Row{Line: line}
+		var object map[string]any
+		err := json.Unmarshal(text, &object)
+		if err != nil {
+			row.Err = fmt.Errorf("invalid JSON: %w", err)
+			rows = append(rows, row)
+			continue
+		}
+		values := make(map[string]string, len(object))
+		for k, v := range object {
+			if s, ok := v.(string); ok {
+				values[k] = s
+			} else if v != nil {
+				values[k] = fmt.Sprint(v)
+			}
+		}
+		row.Licence = mapping.licence(values)
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+// Read parses the rows of a .csv or .jsonl/.ndjson file.
+func Read(path string, mapping Mapping) ([]Row, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Row
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err = readCSV(bytes.NewReader(data), mapping)
+	case ".jsonl", ".ndjson":
+		rows, err = readJSONLines(bytes.NewReader(data), mapping)
+	default:
+		return nil, fmt.Errorf("unsupported input '%s', expected a .csv or .jsonl file", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("input '%s' contains no rows", path)
+	}
+	return rows, nil
+}
+
+// Validate prepares every row with prepare and renders its output file name
+// from nameTemplate, recording problems in Row.Err. It reports whether all
+// rows are valid so nothing is signed unless the whole batch is.
+func Validate(rows []Row, prepare func(licence.Licence) (licence.Licence, error), nameTemplate, directory string, overwrite bool) (bool, error) {
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return false, fmt.Errorf("invalid file name template: %w", err)
+	}
+	keys := make(map[string]int)
+	files := make(map[string]int)
+	valid := true
+	for i := range rows {
+		row := &rows[i]
+		if row.Err == nil {
+			row.Err = validateRow(row, prepare, tmpl, directory, overwrite, keys, files)
+		}
+		if row.Err != nil {
+			valid = false
+		}
+	}
+	return valid, nil
+}
+
+func validateRow(row *Row, prepare func(licence.Licence) (licence.Licence, error), tmpl *template.Template,
+	directory string, overwrite bool, keys, files map[string]int) error {
+	l, err := prepare(row.Licence)
+	if err != nil {
+		return err
+	}
+	for field, date := range map[string]string{"issue_date": l.IssueDate, "expiry_date": l.ExpiryDate} {
+		_, err = time.Parse(time.DateOnly, date)
+		if err != nil {
+			return fmt.Errorf("licence.%s '%s' is not a yyyy-mm-dd date", field, date)
+		}
+	}
+	if line, ok := keys[l.LicenceKey]; ok {
+		return fmt.Errorf("licence key '%s' is already used on line %d", l.LicenceKey, line)
+	}
+	keys[l.LicenceKey] = row.Line
+
+	var name bytes.Buffer
+	err = tmpl.Execute(&name, l)
+	if err != nil {
+		return fmt.Errorf("failed to render file name: %w", err)
+	}
+	if name.Len() == 0 || strings.ContainsAny(name.String(), "/\\") {
+		return fmt.Errorf("invalid file name '%s'", name.String())
+	}
+	file := filepath.Join(directory, name.String())
+	if line, ok := files[file]; ok {
+		return fmt.Errorf("file '%s' is also written by line %d", file, line)
+	}
+	files[file] = row.Line
+	if !overwrite {
+		exists, _, err := fs.Exists(file)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("path '%s' already exists and overwriting is not permitted", file)
+		}
+	}
+	row.Licence = l
+	row.File = file
+	return nil
+}
+
+// Run calls issue for every row using workers goroutines and returns one
+// result per row in input order.
+func Run(rows []Row, workers int, issue func(Row) error) []Result {
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]Result, len(rows))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				row := rows[i]
+				result := Result{Line: row.Line, LicenceKey: row.Licence.LicenceKey}
+				err := issue(row)
+				if err != nil {
+					result.Error = err.Error()
+				} else {
+					result.File = row.File
+				}
+				results[i] = result
+			}
+		}()
+	}
+	for i := range rows {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return results
+}
+
+// Errors turns the invalid rows into results for the summary report.
+func Errors(rows []Row) []Result {
+	var results []Result
+	for _, row := range rows {
+		if row.Err != nil {
+			results = append(results, Result{Line: row.Line, Error: row.Err.Error()})
+		}
+	}
+	return results
+}
@@ -0,0 +1,231 @@
+// Package certificate renders a signed licence as a printable certificate in
+// HTML or PDF, with a QR code carrying the licence for verification.
+package certificate
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/licence"
+	"github.com/skip2/go-qrcode"
+)
+
+type Format int
+
+const (
+	HTML Format = iota
+	PDF
+)
+
+var Formats = map[Format][]string{
+	HTML: {"html"},
+	PDF:  {"pdf"},
+}
+
+var FormatDescription = map[Format]string{
+	HTML: "standalone HTML page with embedded images, printable from a browser.",
+	PDF:  "PDF document.",
+}
+
+var Extensions = map[Format]string{
+	HTML: ".certificate.html",
+	PDF:  ".certificate.pdf",
+}
+
+const qrCodeSize int = 512
+
+// Layout customises the certificate. Empty fields keep their defaults.
+type Layout struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle"`
+	Footer   string `json:"footer"`
+	// Logo is a PNG or JPEG file, relative to the layout file.
+	Logo string `json:"logo"`
+	// AccentColor is the #rrggbb colour of the border and headings.
+	AccentColor string `json:"accent_color"`
+	// PageSize is A4 or Letter and Orientation portrait or landscape.
+	PageSize    string `json:"page_size"`
+	Orientation string `json:"orientation"`
+}
+
+var defaultLayout = Layout{
+	Title:       "Licence Certificate",
+	Subtitle:    "This certifies that",
+	Footer:      "Scan the QR code to read the signed licence and verify it with the issuer's public key.",
+	AccentColor: "#1f4e79",
+	PageSize:    "A4",
+	Orientation: "landscape",
+}
+
+type Options struct {
+	Layout Layout
+	// Template replaces the built-in HTML template. It is not used for PDF.
+	Template string
+}
+
+// certificate holds everything shown on a certificate.
+type certificate struct {
+	licence.Licence
+	Layout
+	Format               string
+	KeyId                string
+	SignatureFingerprint string
+	QRCode               []byte
+	LogoImage            []byte
+	LogoType             string
+	GeneratedAt          time.Time
+}
+
+// LoadLayout reads a JSON layout file.
+func LoadLayout(path string) (Layout, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return Layout{}, err
+	}
+	var layout Layout
+	err = json.Unmarshal(data, &layout)
+	if err != nil {
+		return Layout{}, fmt.Errorf("invalid layout '%s': %w", path, err)
+	}
+	if layout.Logo != "" && !filepath.IsAbs(layout.Logo) {
+		layout.Logo = filepath.Join(filepath.Dir(path), layout.Logo)
+	}
+	return layout, nil
+}
+
+func (l Layout) withDefaults() Layout {
+	fill := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
+		}
+	}
+	fill(&l.Title, defaultLayout.Title)
+	fill(&l.Subtitle, defaultLayout.Subtitle)
+	fill(&l.Footer, defaultLayout.Footer)
+	fill(&l.AccentColor, defaultLayout.AccentColor)
+	fill(&l.PageSize, defaultLayout.PageSize)
+	fill(&l.Orientation, defaultLayout.Orientation)
+	return l
+}
+
+func (l Layout) validate() error {
+	if _, _, _, err := parseColor(l.AccentColor); err != nil {
+		return err
+	}
+	switch strings.ToLower(l.PageSize) {
+	case "a4", "letter":
+	default:
+		return fmt.Errorf("unsupported page size '%s', expected A4 or Letter", l.PageSize)
+	}
+	switch strings.ToLower(l.Orientation) {
+	case "portrait", "landscape":
+	default:
+		return fmt.Errorf("unsupported orientation '%s', expected portrait or landscape", l.Orientation)
+	}
+	return nil
+}
+
+func parseColor(color string) (r, g, b int, err error) {
+	value, found := strings.CutPrefix(color, "#")
+	if !found || len(value) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid colour '%s', expected #rrggbb", color)
+	}
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid colour '%s', expected #rrggbb", color)
+	}
+	return int(rgb >> 16), int(rgb >> 8 & 0xff), int(rgb & 0xff), nil
+}
+
+// compact returns the signed licence in the smallest text form of its
+// format, which the QR code carries.
+func compact(data []byte, format licence.Format) (string, error) {
+	switch format {
+	case licence.JSON, licence.JWS_JSON:
+		var buf bytes.Buffer
+		err := json.Compact(&buf, data)
+		if err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	case licence.COSE:
+		return base64.RawURLEncoding.EncodeToString(data), nil
+	default:
+		return string(bytes.TrimSpace(data)), nil
+	}
+}
+
+func newCertificate(data []byte, layout Layout) (certificate, error) {
+	layout = layout.withDefaults()
+	err := layout.validate()
+	if err != nil {
+		return certificate{}, err
+	}
+
+	format, err := licence.DetectFormat(data)
+	if err != nil {
+		return certificate{}, err
+	}
+	l, keyId, err := licence.Decode(data)
+	if err != nil {
+		return certificate{}, err
+	}
+	fingerprint, err := licence.SignatureFingerprint(data)
+	if err != nil {
+		return certificate{}, err
+	}
+	payload, err := compact(data, format)
+	if err != nil {
+		return certificate{}, err
+	}
+	qrCode, err := qrcode.Encode(payload, qrcode.Low, qrCodeSize)
+	if err != nil {
+		return certificate{}, fmt.Errorf("licence does not fit in a QR code: %w", err)
+	}
+
+	c := certificate{
+		Licence:              l,
+		Layout:               layout,
+		Format:               licence.Formats[format][0],
+		KeyId:                keyId,
+		SignatureFingerprint: fingerprint,
+		QRCode:               qrCode,
+		GeneratedAt:          time.Now(),
+	}
+	if layout.Logo != "" {
+		c.LogoImage, err = fs.ReadFile(layout.Logo)
+		if err != nil {
+			return certificate{}, err
+		}
+		c.LogoType = http.DetectContentType(c.LogoImage)
+		if c.LogoType != "image/png" && c.LogoType != "image/jpeg" {
+			return certificate{}, fmt.Errorf("logo '%s' must be a PNG or JPEG image", layout.Logo)
+		}
+	}
+	return c, nil
+}
+
+// Render creates a certificate for the signed licence in data.
+func Render(data []byte, format Format, options Options) ([]byte, error) {
+	c, err := newCertificate(data, options.Layout)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case HTML:
+		return renderHTML(c, options.Template)
+	case PDF:
+		return renderPDF(c)
+	default:
+		return nil, errors.New("invalid certificate format")
+	}
+}
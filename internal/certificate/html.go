@@ -0,0 +1,93 @@
+package certificate
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// htmlView is the data given to HTML templates. Images are data URIs so the
+// page is self-contained.
+type htmlView struct {
+	certificate
+	QRCodeURL template.URL
+	LogoURL   template.URL
+}
+
+const defaultHTMLTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} - {{.Product}}</title>
+<style>
+@page { size: {{.PageSize}} {{.Orientation}}; margin: 10mm; }
+body { font-family: Helvetica, Arial, sans-serif; color: #222; margin: 0; }
+.certificate { border: 3px double {{.AccentColor}}; padding: 12mm; text-align: center; }
+.logo { max-height: 22mm; margin-bottom: 4mm; }
+h1 { color: {{.AccentColor}}; font-size: 28pt; margin: 0 0 4mm; }
+.subtitle { font-style: italic; margin: 0; }
+.name { font-size: 22pt; font-weight: bold; margin: 3mm 0; }
+.product { font-size: 14pt; margin: 0 0 8mm; }
+.details { display: flex; justify-content: space-between; align-items: flex-end; text-align: left; }
+table { border-collapse: collapse; font-size: 10pt; }
+th { color: {{.AccentColor}}; text-align: left; padding: 1mm 6mm 1mm 0; white-space: nowrap; }
+td { padding: 1mm 0; }
+.fingerprint { font-family: monospace; font-size: 8pt; word-break: break-all; }
+.qr { text-align: center; font-size: 8pt; }
+.qr img { width: 45mm; height: 45mm; }
+footer { margin-top: 6mm; font-size: 8pt; color: #666; }
+</style>
+</head>
+<body>
+<div class="certificate">
+{{if .LogoURL}}<img class="logo" src="{{.LogoURL}}" alt="">{{end}}
+<h1>{{.Title}}</h1>
+<p class="subtitle">{{.Subtitle}}</p>
+<p class="name">{{.Name}}</p>
+<p class="product">is licensed to use {{.Product}} {{.Version}}{{if .Tier}} ({{.Tier}}){{end}}</p>
+<div class="details">
+<table>
+<tr><th>Licence key</th><td>{{.LicenceKey}}</td></tr>
+<tr><th>Email</th><td>{{.Email}}</td></tr>
+<tr><th>Issuer</th><td>{{.Issuer}}</td></tr>
+<tr><th>Issued</th><td>{{.IssueDate}}</td></tr>
+<tr><th>Expires</th><td>{{.ExpiryDate}}</td></tr>
+{{if .Features}}<tr><th>Features</th><td>{{join .Features ", "}}</td></tr>{{end}}
+<tr><th>Format</th><td>{{.Format}}</td></tr>
+{{if .KeyId}}<tr><th>Key ID</th><td class="fingerprint">{{.KeyId}}</td></tr>{{end}}
+<tr><th>Signature SHA-256</th><td class="fingerprint">{{.SignatureFingerprint}}</td></tr>
+</table>
+<div class="qr"><img src="{{.QRCodeURL}}" alt="Signed licence"><br>Signed licence</div>
+</div>
+<footer>{{.Footer}}</footer>
+</div>
+</body>
+</html>
+`
+
+func dataURL(mediaType string, data []byte) template.URL {
+	return template.URL(fmt.Sprintf("data:%s;base64,%s", mediaType, base64.StdEncoding.EncodeToString(data)))
+}
+
+func renderHTML(c certificate, text string) ([]byte, error) {
+	if text == "" {
+		text = defaultHTMLTemplate
+	}
+	tmpl, err := template.New("certificate").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	view := htmlView{certificate: c, QRCodeURL: dataURL("image/png", c.QRCode)}
+	if c.LogoImage != nil {
+		view.LogoURL = dataURL(c.LogoType, c.LogoImage)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, view)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,152 @@
+package certificate
+
+import (
+	"bytes"
+	_ "embed"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfMargin     float64 = 10
+	pdfPadding    float64 = 12
+	pdfLogoHeight float64 = 18
+	pdfQRCodeSize float64 = 45
+	pdfLabelWidth float64 = 38
+	pdfFont       string  = "DejaVuSansCondensed"
+)
+
+// The PDF core fonts only cover Latin-1, so a Unicode font is embedded to
+// render names, products and footers in any script.
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	dejaVuRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	dejaVuBold []byte
+	//go:embed fonts/DejaVuSansCondensed-Oblique.ttf
+	dejaVuOblique []byte
+)
+
+func renderPDF(c certificate) ([]byte, error) {
+	orientation := "L"
+	if strings.EqualFold(c.Orientation, "portrait") {
+		orientation = "P"
+	}
+	size := "A4"
+	if strings.EqualFold(c.PageSize, "letter") {
+		size = "Letter"
+	}
+	pdf := fpdf.New(orientation, "mm", size, "")
+	pdf.AddUTF8FontFromBytes(pdfFont, "", dejaVuRegular)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", dejaVuBold)
+	pdf.AddUTF8FontFromBytes(pdfFont, "I", dejaVuOblique)
+	if pdf.Err() {
+		return nil, pdf.Error()
+	}
+	r, g, b, err := parseColor(c.AccentColor)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf.SetTitle(c.Title, true)
+	pdf.SetAuthor(c.Issuer, true)
+	pdf.SetCreator("file-signer", false)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	width, height := pdf.GetPageSize()
+	left := pdfMargin + pdfPadding
+	contentWidth := width - 2*left
+
+	pdf.SetDrawColor(r, g, b)
+	pdf.SetLineWidth(1)
+	pdf.Rect(pdfMargin, pdfMargin, width-2*pdfMargin, height-2*pdfMargin, "D")
+	pdf.SetLineWidth(0.3)
+	pdf.Rect(pdfMargin+2, pdfMargin+2, width-2*pdfMargin-4, height-2*pdfMargin-4, "D")
+
+	y := pdfMargin + pdfPadding
+	if c.LogoImage != nil {
+		imageType := "PNG"
+		if c.LogoType == "image/jpeg" {
+			imageType = "JPG"
+		}
+		options := fpdf.ImageOptions{ImageType: imageType}
+		info := pdf.RegisterImageOptionsReader("logo", options, bytes.NewReader(c.LogoImage))
+		if pdf.Err() {
+			return nil, pdf.Error()
+		}
+		logoWidth := pdfLogoHeight * info.Width() / info.Height()
+		pdf.ImageOptions("logo", (width-logoWidth)/2, y, logoWidth, pdfLogoHeight, false, options, 0, "")
+		y += pdfLogoHeight + 4
+	}
+
+	pdf.SetXY(left, y)
+	pdf.SetTextColor(r, g, b)
+	pdf.SetFont(pdfFont, "B", 28)
+	pdf.CellFormat(contentWidth, 14, c.Title, "", 1, "C", false, 0, "")
+	pdf.SetTextColor(34, 34, 34)
+	pdf.SetX(left)
+	pdf.SetFont(pdfFont, "I", 13)
+	pdf.CellFormat(contentWidth, 8, c.Subtitle, "", 1, "C", false, 0, "")
+	pdf.SetX(left)
+	pdf.SetFont(pdfFont, "B", 22)
+	pdf.CellFormat(contentWidth, 12, c.Name, "", 1, "C", false, 0, "")
+	product := "is licensed to use " + c.Product + " " + c.Version
+	if c.Tier != "" {
+		product += " (" + c.Tier + ")"
+	}
+	pdf.SetX(left)
+	pdf.SetFont(pdfFont, "", 14)
+	pdf.CellFormat(contentWidth, 8, product, "", 1, "C", false, 0, "")
+
+	details := [][2]string{
+		{"Licence key", c.LicenceKey},
+		{"Email", c.Email},
+		{"Issuer", c.Issuer},
+		{"Issued", c.IssueDate},
+		{"Expires", c.ExpiryDate},
+	}
+	if len(c.Features) > 0 {
+		details = append(details, [2]string{"Features", strings.Join(c.Features, ", ")})
+	}
+	details = append(details, [2]string{"Format", c.Format})
+	if c.KeyId != "" {
+		details = append(details, [2]string{"Key ID", c.KeyId})
+	}
+	details = append(details, [2]string{"Signature SHA-256", c.SignatureFingerprint})
+
+	valueWidth := contentWidth - pdfLabelWidth - pdfQRCodeSize - 8
+	pdf.SetY(pdf.GetY() + 8)
+	detailsTop := pdf.GetY()
+	for _, detail := range details {
+		pdf.SetX(left)
+		pdf.SetTextColor(r, g, b)
+		pdf.SetFont(pdfFont, "B", 10)
+		pdf.CellFormat(pdfLabelWidth, 6, detail[0], "", 0, "L", false, 0, "")
+		pdf.SetTextColor(34, 34, 34)
+		if detail[0] == "Key ID" || detail[0] == "Signature SHA-256" {
+			pdf.SetFont("Courier", "", 8)
+		} else {
+			pdf.SetFont(pdfFont, "", 10)
+		}
+		pdf.MultiCell(valueWidth, 6, detail[1], "", "L", false)
+	}
+
+	qrX := width - left - pdfQRCodeSize
+	pdf.RegisterImageOptionsReader("qrcode", fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(c.QRCode))
+	pdf.ImageOptions("qrcode", qrX, detailsTop, pdfQRCodeSize, pdfQRCodeSize, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	pdf.SetXY(qrX, detailsTop+pdfQRCodeSize)
+	pdf.SetFont(pdfFont, "", 8)
+	pdf.CellFormat(pdfQRCodeSize, 5, "Signed licence", "", 0, "C", false, 0, "")
+
+	pdf.SetXY(left, height-pdfMargin-pdfPadding-8)
+	pdf.SetTextColor(102, 102, 102)
+	pdf.MultiCell(contentWidth, 4, c.Footer, "", "C", false)
+
+	var buf bytes.Buffer
+	err = pdf.Output(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
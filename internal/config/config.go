@@ -0,0 +1,249 @@
+// Package config provides flag defaults from a project-local and a user
+// configuration file, named contexts and FILE_SIGNER_* environment variables.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	LOCAL_FILE_NAME string = ".file-signer.yaml"
+	USER_FILE_NAME  string = "config.yaml"
+	ENV_PREFIX      string = "FILE_SIGNER_"
+	CONTEXT_ENV     string = ENV_PREFIX + "CONTEXT"
+	applicationDir  string = "file-signer"
+)
+
+// Value is a flag value. Lists set repeatable flags once per item.
+type Value []string
+
+func (v *Value) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*v = Value{node.Value}
+		return nil
+	case yaml.SequenceNode:
+		var items []string
+		err := node.Decode(&items)
+		if err != nil {
+			return err
+		}
+		*v = items
+		return nil
+	default:
+		return fmt.Errorf("line %d: expected a value or a list of values", node.Line)
+	}
+}
+
+// Values maps flag names to their values.
+type Values map[string]Value
+
+// File is one configuration file. Defaults apply to every command that has
+// the flag, Commands to a single command such as "licence sign" and
+// Contexts only when that context is selected.
+type File struct {
+	Path     string            `yaml:"-"`
+	Context  string            `yaml:"context"`
+	Defaults Values            `yaml:"defaults"`
+	Commands map[string]Values `yaml:"commands"`
+	Contexts map[string]Values `yaml:"contexts"`
+}
+
+type Config struct {
+	// Files holds the loaded files, the project-local file first.
+	Files []File
+	// Context is the selected context, if any, and ContextSource where it
+	// was selected.
+	Context       string
+	ContextSource string
+}
+
+// Setting is a configured flag value and where it came from.
+type Setting struct {
+	Value  Value
+	Source string
+}
+
+// UserPath is the location of the user configuration file.
+func UserPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, applicationDir, USER_FILE_NAME), nil
+}
+
+// LocalPath finds the project-local configuration file in the working
+// directory or its closest parent that has one.
+func LocalPath() (string, bool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+	for {
+		path := filepath.Join(dir, LOCAL_FILE_NAME)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+func loadFile(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+	var f File
+	err = yaml.Unmarshal(data, &f)
+	if err != nil {
+		return File{}, fmt.Errorf("invalid configuration file '%s': %w", path, err)
+	}
+	f.Path = path
+	return f, nil
+}
+
+// Load reads the configuration files that exist and selects context, or the
+// context named by FILE_SIGNER_CONTEXT or the configuration files.
+func Load(context string) (*Config, error) {
+	var paths []string
+	if path, ok := LocalPath(); ok {
+		paths = append(paths, path)
+	}
+	userPath, err := UserPath()
+	if err == nil {
+		paths = append(paths, userPath)
+	}
+
+	c := &Config{}
+	for _, path := range paths {
+		f, err := loadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.Files = append(c.Files, f)
+	}
+
+	switch {
+	case context != "":
+		c.Context, c.ContextSource = context, "--context"
+	case os.Getenv(CONTEXT_ENV) != "":
+		c.Context, c.ContextSource = os.Getenv(CONTEXT_ENV), CONTEXT_ENV
+	default:
+		for _, f := range c.Files {
+			if f.Context != "" {
+				c.Context, c.ContextSource = f.Context, f.Path
+				break
+			}
+		}
+	}
+	if c.Context != "" && !c.hasContext(c.Context) {
+		return nil, fmt.Errorf("unknown context '%s' selected by %s", c.Context, c.ContextSource)
+	}
+	return c, nil
+}
+
+func (c *Config) hasContext(name string) bool {
+	for _, f := range c.Files {
+		if _, ok := f.Contexts[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvName is the environment variable that sets flag, e.g.
+// FILE_SIGNER_PRIVATE_KEY for --private-key.
+func EnvName(flag string) string {
+	return ENV_PREFIX + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// Lookup finds the value of flag for command, such as "licence sign". The
+// environment takes precedence over the selected context, then the command
+// section and finally the defaults, with the project-local file ahead of the
+// user file at each level.
+func (c *Config) Lookup(command, flag string) (Setting, bool) {
+	name := EnvName(flag)
+	if value, ok := os.LookupEnv(name); ok {
+		return Setting{Value: Value{value}, Source: name}, true
+	}
+	if c.Context != "" {
+		for _, f := range c.Files {
+			if value, ok := f.Contexts[c.Context][flag]; ok {
+				return Setting{Value: value, Source: fmt.Sprintf("%s (context %s)", f.Path, c.Context)}, true
+			}
+		}
+	}
+	if command != "" {
+		for _, f := range c.Files {
+			if value, ok := f.Commands[command][flag]; ok {
+				return Setting{Value: value, Source: fmt.Sprintf("%s (%s)", f.Path, command)}, true
+			}
+		}
+	}
+	for _, f := range c.Files {
+		if value, ok := f.Defaults[flag]; ok {
+			return Setting{Value: value, Source: f.Path}, true
+		}
+	}
+	return Setting{}, false
+}
+
+// Flags lists the flags configured for every command and those configured
+// for each command section, sorted by name.
+func (c *Config) Flags() (global []string, commands map[string][]string) {
+	globalSet := map[string]bool{}
+	commandSets := map[string]map[string]bool{}
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(name, ENV_PREFIX) && name != CONTEXT_ENV {
+			globalSet[strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, ENV_PREFIX)), "_", "-")] = true
+		}
+	}
+	for _, f := range c.Files {
+		for flag := range f.Defaults {
+			globalSet[flag] = true
+		}
+		for flag := range f.Contexts[c.Context] {
+			globalSet[flag] = true
+		}
+		for command, values := range f.Commands {
+			if commandSets[command] == nil {
+				commandSets[command] = map[string]bool{}
+			}
+			for flag := range values {
+				commandSets[command][flag] = true
+			}
+		}
+	}
+
+	commands = map[string][]string{}
+	for command, set := range commandSets {
+		commands[command] = sortedKeys(set)
+	}
+	return sortedKeys(globalSet), commands
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,9 +4,26 @@ const (
 	SCHEMA_FILE_NAME         = "licence.schema.json"
 	LICENCE_FILE_NAME        = "licence.json"
 	SIGNED_LICENCE_FILE_NAME = "licence.signed.json"
+
+	SIGNED_LICENCE_PEM_FILE_NAME      = "licence.signed.pem"
+	SIGNED_LICENCE_JWS_FILE_NAME      = "licence.signed.jws"
+	SIGNED_LICENCE_JWS_JSON_FILE_NAME = "licence.signed.jws.json"
+	SIGNED_LICENCE_COSE_FILE_NAME     = "licence.signed.cbor"
+	SIGNED_LICENCE_PASETO_FILE_NAME   = "licence.signed.paseto"
+
+	ISSUANCE_POLICY_FILE_NAME = "issuance-policy.json"
 )
 
 const (
 	PRIVATE_KEY_FILE_NAME = "private.key"
 	PUBLIC_KEY_FILE_NAME  = "public.pem"
+	KEY_SHARE_FILE_NAME   = "key.share-%d.pem"
+)
+
+const (
+	TRANSPARENCY_LOG_DIRECTORY = "transparency-log"
+	INCLUSION_PROOF_EXTENSION  = ".proof.json"
 )
+
+// STANDARD_STREAM used as a file path means standard input or output.
+const STANDARD_STREAM = "-"
@@ -0,0 +1,193 @@
+package detached
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/sign"
+	"github.com/eslam-allam/file-signer/internal/timestamp"
+)
+
+type Format int
+
+const (
+	NATIVE Format = iota
+	MINISIGN
+	SSHSIG
+)
+
+const (
+	signatureBlock  string = "SIGNATURE"
+	timestampBlock  string = "TIMESTAMP"
+	algorithmHeader string = "Algorithm"
+	keyIdHeader     string = "Key-Id"
+)
+
+var Formats = map[Format][]string{
+	NATIVE:   {"native"},
+	MINISIGN: {"minisign"},
+	SSHSIG:   {"sshsig"},
+}
+
+var FormatDescription = map[Format]string{
+	NATIVE:   "PEM SIGNATURE block with Algorithm and Key-Id headers.",
+	MINISIGN: "minisign/signify compatible .minisig file. Requires an Ed25519 key.",
+	SSHSIG:   "OpenSSH signature verifiable with ssh-keygen -Y verify.",
+}
+
+var Extensions = map[Format]string{
+	NATIVE:   ".sig",
+	MINISIGN: ".minisig",
+	SSHSIG:   ".sshsig",
+}
+
+type SignOptions struct {
+	// FileName is recorded in the default minisign trusted comment.
+	FileName         string
+	TrustedComment   string
+	UntrustedComment string
+	// Namespace scopes SSH signatures to a purpose. Defaults to DEFAULT_NAMESPACE.
+	Namespace string
+	// TSA is the URL of an RFC 3161 time-stamping authority used to
+	// timestamp native signatures.
+	TSA string
+}
+
+type VerifyOptions struct {
+	// Namespace expected in SSH signatures. Defaults to DEFAULT_NAMESPACE.
+	Namespace string
+	// AllowedSigners is an OpenSSH allowed_signers file. When set, SSH
+	// signatures are trusted based on it instead of the public key.
+	AllowedSigners []byte
+	// Identity restricts AllowedSigners to entries matching this principal.
+	Identity string
+	// Timestamp controls validation of timestamps embedded in native signatures.
+	Timestamp timestamp.VerifyOptions
+}
+
+func Sign(privateKey crypto.PrivateKey, data []byte, format Format, options SignOptions) ([]byte, error) {
+	if options.TSA != "" && format != NATIVE {
+		return nil, errors.New("timestamps are only supported by native signatures")
+	}
+	switch format {
+	case NATIVE:
+		return signNative(privateKey, data, options)
+	case MINISIGN:
+		return signMinisign(privateKey, data, options)
+	case SSHSIG:
+		return signSSHSIG(privateKey, data, options)
+	default:
+		return nil, errors.New("invalid signature format")
+	}
+}
+
+func DetectFormat(signature []byte) (Format, error) {
+	trimmed := bytes.TrimSpace(signature)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN "+sshsigBlock+"-----")):
+		return SSHSIG, nil
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN ")):
+		return NATIVE, nil
+	case bytes.HasPrefix(trimmed, []byte(untrustedCommentPrefix)):
+		return MINISIGN, nil
+	default:
+		return 0, errors.New("unrecognised signature format")
+	}
+}
+
+// Verify checks signature over data. publicKey may be a PEM public key or,
+// for minisign and SSH signatures, a minisign or OpenSSH public key.
+func Verify(data, signature, publicKey []byte, options VerifyOptions) error {
+	format, err := DetectFormat(signature)
+	if err != nil {
+		return err
+	}
+	// Minisign and SSH signatures cannot carry a timestamp, so there is no
+	// way to tell whether they were made before the key was revoked.
+	if format != NATIVE && !options.Timestamp.RevokedAt.IsZero() {
+		return errors.New("revocation times can only be checked for native signatures, minisign and SSH signatures carry no timestamp")
+	}
+	switch format {
+	case NATIVE:
+		public, err := key.ParsePublicKey(publicKey)
+		if err != nil {
+			return err
+		}
+		return verifyNative(data, signature, public, options)
+	case MINISIGN:
+		return verifyMinisign(data, signature, publicKey)
+	case SSHSIG:
+		return verifySSHSIG(data, signature, publicKey, options)
+	default:
+		return errors.New("invalid signature format")
+	}
+}
+
+func signNative(privateKey crypto.PrivateKey, data []byte, options SignOptions) ([]byte, error) {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key is not a signer")
+	}
+	signature, err := sign.SignMessage(signer, data)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := sign.Algorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	keyId, err := key.Fingerprint(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	armored := pem.EncodeToMemory(&pem.Block{
+		Type:    signatureBlock,
+		Headers: map[string]string{algorithmHeader: algorithm, keyIdHeader: keyId},
+		Bytes:   signature,
+	})
+	if options.TSA != "" {
+		token, err := timestamp.Request(options.TSA, signature)
+		if err != nil {
+			return nil, err
+		}
+		armored = append(armored, pem.EncodeToMemory(&pem.Block{Type: timestampBlock, Bytes: token})...)
+	}
+	return armored, nil
+}
+
+func verifyNative(data, signature []byte, publicKey crypto.PublicKey, options VerifyOptions) error {
+	block, rest := pem.Decode(signature)
+	if block == nil || block.Type != signatureBlock {
+		return fmt.Errorf("block '%s' not found", signatureBlock)
+	}
+	var token []byte
+	if next, _ := pem.Decode(rest); next != nil && next.Type == timestampBlock {
+		token = next.Bytes
+	}
+	algorithm, err := sign.Algorithm(publicKey)
+	if err != nil {
+		return err
+	}
+	if block.Headers[algorithmHeader] != algorithm {
+		return fmt.Errorf("file was signed using '%s' but public key uses '%s'", block.Headers[algorithmHeader], algorithm)
+	}
+	if signedKeyId, ok := block.Headers[keyIdHeader]; ok {
+		keyId, err := key.Fingerprint(publicKey)
+		if err != nil {
+			return err
+		}
+		if signedKeyId != keyId {
+			return fmt.Errorf("file was signed by key '%s' but public key is '%s'", signedKeyId, keyId)
+		}
+	}
+	err = sign.VerifySignature(block.Bytes, data, publicKey)
+	if err != nil {
+		return err
+	}
+	_, err = timestamp.Check(token, block.Bytes, options.Timestamp)
+	return err
+}
@@ -0,0 +1,60 @@
+package detached
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/timestamp"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestVerifyRevokedAt(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pemPublic, err := key.MarshalKeyPair(private, public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minisignPublic, err := key.MarshalMinisignPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeys := map[Format][]byte{
+		NATIVE:   pemPublic,
+		MINISIGN: minisignPublic,
+		SSHSIG:   ssh.MarshalAuthorizedKey(sshPublic),
+	}
+	options := VerifyOptions{Timestamp: timestamp.VerifyOptions{RevokedAt: time.Now()}}
+
+	data := []byte("licence bundle")
+	for format, publicKey := range publicKeys {
+		signature, err := Sign(private, data, format, SignOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Verify(data, signature, publicKey, VerifyOptions{})
+		if err != nil {
+			t.Fatalf("%s: verify: %v", Formats[format][0], err)
+		}
+
+		err = Verify(data, signature, publicKey, options)
+		if format == NATIVE {
+			if !errors.Is(err, timestamp.ErrRevoked) {
+				t.Fatalf("untimestamped native signature after revocation: %v, want ErrRevoked", err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), "only be checked for native signatures") {
+			t.Fatalf("%s signature with a revocation time: %v", Formats[format][0], err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package detached
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/sign"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	untrustedCommentPrefix string = "untrusted comment: "
+	trustedCommentPrefix   string = "trusted comment: "
+)
+
+var (
+	// Signature algorithm identifiers. "Ed" signs the file itself, "ED"
+	// signs its BLAKE2b-512 digest and is what minisign produces by default.
+	minisignLegacy    = [2]byte{'E', 'd'}
+	minisignPrehashed = [2]byte{'E', 'D'}
+)
+
+func loadMinisignPublicKey(data []byte) (key.MinisignPublicKey, error) {
+	public, err := key.ParsePublicKey(data)
+	if err != nil {
+		return key.ParseMinisignPublicKey(data)
+	}
+	edPublic, ok := public.(ed25519.PublicKey)
+	if !ok {
+		return key.MinisignPublicKey{}, errors.New("minisign requires an Ed25519 key")
+	}
+	keyId, err := key.MinisignKeyId(edPublic)
+	if err != nil {
+		return key.MinisignPublicKey{}, err
+	}
+	return key.MinisignPublicKey{KeyId: keyId, Key: edPublic}, nil
+}
+
+func signMinisign(privateKey crypto.PrivateKey, data []byte, options SignOptions) ([]byte, error) {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key is not a signer")
+	}
+	public, ok := signer.Public().(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("minisign requires an Ed25519 key")
+	}
+	keyId, err := key.MinisignKeyId(public)
+	if err != nil {
+		return nil, err
+	}
+
+	untrustedComment := options.UntrustedComment
+	if untrustedComment == "" {
+		untrustedComment = "signature from file-signer secret key " + key.FormatMinisignKeyId(keyId)
+	}
+	trustedComment := options.TrustedComment
+	if trustedComment == "" {
+		trustedComment = fmt.Sprintf("timestamp:%d\tfile:%s\thashed", time.Now().Unix(), filepath.Base(options.FileName))
+	}
+	if strings.ContainsAny(untrustedComment+trustedComment, "\r\n") {
+		return nil, errors.New("minisign comments cannot contain line breaks")
+	}
+
+	digest := blake2b.Sum512(data)
+	signature, err := sign.SignMessage(signer, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	globalSignature, err := sign.SignMessage(signer, append(bytes.Clone(signature), trustedComment...))
+	if err != nil {
+		return nil, err
+	}
+
+	raw := append(minisignPrehashed[:], keyId[:]...)
+	raw = append(raw, signature...)
+	return []byte(untrustedCommentPrefix + untrustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(raw) + "\n" +
+		trustedCommentPrefix + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(globalSignature) + "\n"), nil
+}
+
+func verifyMinisign(data, signatureFile, publicKey []byte) error {
+	public, err := loadMinisignPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(signatureFile), "\r\n", "\n"), "\n")
+	if len(lines) < 4 {
+		return errors.New("incomplete minisign signature")
+	}
+	if !strings.HasPrefix(lines[0], untrustedCommentPrefix) {
+		return errors.New("minisign signature must start with an untrusted comment")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil {
+		return fmt.Errorf("invalid minisign signature encoding: %w", err)
+	}
+	if len(raw) != 2+key.MINISIGN_KEY_ID_SIZE+ed25519.SignatureSize {
+		return errors.New("invalid minisign signature length")
+	}
+	if !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return errors.New("minisign signature is missing its trusted comment")
+	}
+	trustedComment := strings.TrimPrefix(lines[2], trustedCommentPrefix)
+	globalSignature, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil {
+		return fmt.Errorf("invalid minisign global signature encoding: %w", err)
+	}
+
+	var algorithm [2]byte
+	copy(algorithm[:], raw[:2])
+	keyId := raw[2 : 2+key.MINISIGN_KEY_ID_SIZE]
+	signature := raw[2+key.MINISIGN_KEY_ID_SIZE:]
+	if !bytes.Equal(keyId, public.KeyId[:]) {
+		return fmt.Errorf("file was signed by minisign key %s but public key is %s",
+			key.FormatMinisignKeyId([key.MINISIGN_KEY_ID_SIZE]byte(keyId)), key.FormatMinisignKeyId(public.KeyId))
+	}
+
+	message := data
+	switch algorithm {
+	case minisignPrehashed:
+		digest := blake2b.Sum512(data)
+		message = digest[:]
+	case minisignLegacy:
+	default:
+		return errors.New("unsupported minisign signature algorithm")
+	}
+	if !ed25519.Verify(public.Key, message, signature) {
+		return errors.New("invalid signature")
+	}
+	if !ed25519.Verify(public.Key, append(bytes.Clone(signature), trustedComment...), globalSignature) {
+		return errors.New("invalid global signature")
+	}
+	return nil
+}
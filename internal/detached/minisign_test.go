@@ -0,0 +1,106 @@
+package detached
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+)
+
+// Signatures of the file "test" made by the minisign tool, as published with
+// the reference Go implementation (github.com/jedisct1/go-minisign).
+const (
+	minisignReferencePublicKey = "untrusted comment: minisign public key E7620F1842B4E81F\n" +
+		"RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3\n"
+	minisignReferencePrehashed = "untrusted comment: signature from minisign secret key\n" +
+		"RUQf6LRCGA9i559r3g7V1qNyJDApGip8MfqcadIgT9CuhV3EMhHoN1mGTkUidF/z7SrlQgXdy8ofjb7bNJJylDOocrCo8KLzZwo=\n" +
+		"trusted comment: timestamp:1635443258\tfile:test\thashed\n" +
+		"/cj37GK60vryibFn+ftOgbCvW9NKhKYgjVpFFQUcWPAnjO23wrvVDTt7cloNC06maoBli9q6qwZDXXoaxweICQ==\n"
+	minisignReferenceLegacy = "untrusted comment: signature from minisign secret key\n" +
+		"RWQf6LRCGA9i59SLOFxz6NxvASXDJeRtuZykwQepbDEGt87ig1BNpWaVWuNrm73YiIiJbq71Wi+dP9eKL8OC351vwIasSSbXxwA=\n" +
+		"trusted comment: timestamp:1635442742\tfile:test\n" +
+		"0YteLgV960ia80vnA/fHbvkyjl/IoP/HNOCaZfrF0CdhAlp7ok+Tpkya+VpWPX5C/Is3q8a/kEDSY7fBmmgJCg==\n"
+)
+
+func TestVerifyMinisignReference(t *testing.T) {
+	for name, signature := range map[string]string{"prehashed": minisignReferencePrehashed, "legacy": minisignReferenceLegacy} {
+		t.Run(name, func(t *testing.T) {
+			err := Verify([]byte("test"), []byte(signature), []byte(minisignReferencePublicKey), VerifyOptions{})
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyMinisignTampered(t *testing.T) {
+	lines := strings.SplitAfter(minisignReferencePrehashed, "\n")
+	flip := func(line string) string {
+		// Changing the first base64 digit after the algorithm and key ID
+		// alters the Ed25519 signature itself.
+		b := []byte(line)
+		if b[14] == 'A' {
+			b[14] = 'B'
+		} else {
+			b[14] = 'A'
+		}
+		return string(b)
+	}
+	cases := map[string]struct {
+		data      string
+		signature string
+	}{
+		"file": {
+			data:      "tesT",
+			signature: minisignReferencePrehashed,
+		},
+		"trusted comment": {
+			data:      "test",
+			signature: lines[0] + lines[1] + "trusted comment: timestamp:1635443258\tfile:other\thashed\n" + lines[3],
+		},
+		"signature": {
+			data:      "test",
+			signature: lines[0] + flip(lines[1]) + lines[2] + lines[3],
+		},
+		"global signature": {
+			data:      "test",
+			signature: lines[0] + lines[1] + lines[2] + flip(lines[3]),
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Verify([]byte(c.data), []byte(c.signature), []byte(minisignReferencePublicKey), VerifyOptions{})
+			if err == nil {
+				t.Fatal("tampered signature verified")
+			}
+		})
+	}
+}
+
+func TestMinisignRoundTrip(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := key.MarshalMinisignPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("licence bundle")
+	signature, err := Sign(private, data, MINISIGN, SignOptions{FileName: "bundle.tar", TrustedComment: "release 1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Verify(data, signature, publicKey, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	err = Verify(data, signature, []byte(minisignReferencePublicKey), VerifyOptions{})
+	if err == nil {
+		t.Fatal("signature verified with another key")
+	}
+}
@@ -0,0 +1,310 @@
+package detached
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+	"golang.org/x/crypto/ssh"
+)
+
+// The SSH signature format is specified in OpenSSH's PROTOCOL.sshsig.
+const (
+	sshsigMagic     string = "SSHSIG"
+	sshsigVersion   uint32 = 1
+	sshsigHash      string = "sha512"
+	sshsigBlock     string = "SSH SIGNATURE"
+	sshsigLineWidth        = 70
+
+	DEFAULT_NAMESPACE string = "file"
+)
+
+type sshsigBlob struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Signature     []byte
+}
+
+type sshsigSignedData struct {
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Hash          []byte
+}
+
+func sshsigMessage(data []byte, namespace, hashAlgorithm string) ([]byte, error) {
+	var digest []byte
+	switch hashAlgorithm {
+	case "sha512":
+		sum := sha512.Sum512(data)
+		digest = sum[:]
+	case "sha256":
+		sum := sha256.Sum256(data)
+		digest = sum[:]
+	default:
+		return nil, fmt.Errorf("unsupported SSH signature hash '%s'", hashAlgorithm)
+	}
+	return append([]byte(sshsigMagic), ssh.Marshal(sshsigSignedData{
+		Namespace:     namespace,
+		HashAlgorithm: hashAlgorithm,
+		Hash:          digest,
+	})...), nil
+}
+
+func sshSigner(privateKey crypto.PrivateKey) (ssh.AlgorithmSigner, error) {
+	var signer ssh.Signer
+	var err error
+	switch privateKey := privateKey.(type) {
+	case ssh.AlgorithmSigner:
+		return privateKey, nil
+	case crypto.Signer:
+		signer, err = ssh.NewSignerFromSigner(privateKey)
+	default:
+		return nil, errors.New("private key is not a signer")
+	}
+	if err != nil {
+		return nil, err
+	}
+	algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+	if !ok {
+		return nil, errors.New("ssh signer does not support signature algorithm selection")
+	}
+	return algorithmSigner, nil
+}
+
+func signSSHSIG(privateKey crypto.PrivateKey, data []byte, options SignOptions) ([]byte, error) {
+	signer, err := sshSigner(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	namespace := options.Namespace
+	if namespace == "" {
+		namespace = DEFAULT_NAMESPACE
+	}
+
+	// PROTOCOL.sshsig forbids SHA-1 RSA signatures, so always ask for rsa-sha2-512.
+	algorithm := ""
+	if signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		algorithm = ssh.KeyAlgoRSASHA512
+	}
+	message, err := sshsigMessage(data, namespace, sshsigHash)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signer.SignWithAlgorithm(rand.Reader, message, algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	blob := append([]byte(sshsigMagic), ssh.Marshal(sshsigBlob{
+		Version:       sshsigVersion,
+		PublicKey:     signer.PublicKey().Marshal(),
+		Namespace:     namespace,
+		HashAlgorithm: sshsigHash,
+		Signature:     ssh.Marshal(signature),
+	})...)
+
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	var armored strings.Builder
+	armored.WriteString("-----BEGIN " + sshsigBlock + "-----\n")
+	for len(encoded) > sshsigLineWidth {
+		armored.WriteString(encoded[:sshsigLineWidth] + "\n")
+		encoded = encoded[sshsigLineWidth:]
+	}
+	armored.WriteString(encoded + "\n")
+	armored.WriteString("-----END " + sshsigBlock + "-----\n")
+	return []byte(armored.String()), nil
+}
+
+type allowedSigner struct {
+	principals  []string
+	namespaces  []string
+	validAfter  time.Time
+	validBefore time.Time
+	key         ssh.PublicKey
+}
+
+// sshTimeLayouts are the absolute times accepted by valid-after and
+// valid-before, in local time unless suffixed with Z.
+var sshTimeLayouts = []string{"20060102", "200601021504", "20060102150405"}
+
+func parseSSHTime(value string) (time.Time, error) {
+	location := time.Local
+	if trimmed, utc := strings.CutSuffix(value, "Z"); utc {
+		value = trimmed
+		location = time.UTC
+	}
+	for _, layout := range sshTimeLayouts {
+		if len(value) != len(layout) {
+			continue
+		}
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s', expected YYYYMMDD[HHMM[SS]][Z]", value)
+}
+
+// validAt reports whether the signer's validity interval contains t.
+func (s allowedSigner) validAt(t time.Time) bool {
+	if !s.validAfter.IsZero() && t.Before(s.validAfter) {
+		return false
+	}
+	return s.validBefore.IsZero() || t.Before(s.validBefore)
+}
+
+// parseAllowedSigners reads an OpenSSH allowed_signers file. The namespaces,
+// valid-after and valid-before options are honoured; certificate authority
+// entries are skipped.
+func parseAllowedSigners(data []byte) ([]allowedSigner, error) {
+	var signers []allowedSigner
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		principals, rest, _ := strings.Cut(line, " ")
+		public, _, options, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(rest)))
+		if err != nil {
+			return nil, fmt.Errorf("allowed signers line %d: %w", lineNumber, err)
+		}
+		signer := allowedSigner{principals: strings.Split(principals, ","), key: public}
+		skip := false
+		for _, option := range options {
+			name, value, _ := strings.Cut(option, "=")
+			switch strings.ToLower(name) {
+			case "cert-authority":
+				skip = true
+			case "namespaces":
+				signer.namespaces = strings.Split(strings.Trim(value, `"`), ",")
+			case "valid-after":
+				signer.validAfter, err = parseSSHTime(strings.Trim(value, `"`))
+			case "valid-before":
+				signer.validBefore, err = parseSSHTime(strings.Trim(value, `"`))
+			}
+			if err != nil {
+				return nil, fmt.Errorf("allowed signers line %d: %s: %w", lineNumber, name, err)
+			}
+		}
+		if !skip {
+			signers = append(signers, signer)
+		}
+	}
+	return signers, scanner.Err()
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func parseSSHPublicKey(data []byte) (ssh.PublicKey, error) {
+	public, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err == nil {
+		return public, nil
+	}
+	pemPublic, pemErr := key.ParsePublicKey(data)
+	if pemErr != nil {
+		return nil, errors.New("public key is neither an OpenSSH nor a PEM public key")
+	}
+	return ssh.NewPublicKey(pemPublic)
+}
+
+func verifySSHSIG(data, signature, publicKey []byte, options VerifyOptions) error {
+	block, _ := pem.Decode(signature)
+	if block == nil || block.Type != sshsigBlock {
+		return fmt.Errorf("block '%s' not found", sshsigBlock)
+	}
+	if !bytes.HasPrefix(block.Bytes, []byte(sshsigMagic)) {
+		return errors.New("invalid SSH signature magic")
+	}
+	var blob sshsigBlob
+	err := ssh.Unmarshal(block.Bytes[len(sshsigMagic):], &blob)
+	if err != nil {
+		return fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	if blob.Version != sshsigVersion {
+		return fmt.Errorf("unsupported SSH signature version %d", blob.Version)
+	}
+	namespace := options.Namespace
+	if namespace == "" {
+		namespace = DEFAULT_NAMESPACE
+	}
+	if blob.Namespace != namespace {
+		return fmt.Errorf("signature namespace '%s' does not match expected namespace '%s'", blob.Namespace, namespace)
+	}
+	signer, err := ssh.ParsePublicKey(blob.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid SSH signature public key: %w", err)
+	}
+
+	if options.AllowedSigners != nil {
+		signers, err := parseAllowedSigners(options.AllowedSigners)
+		if err != nil {
+			return err
+		}
+		trusted := false
+		now := time.Now()
+		for _, allowed := range signers {
+			if !bytes.Equal(allowed.key.Marshal(), signer.Marshal()) {
+				continue
+			}
+			if options.Identity != "" && !matchesAny(allowed.principals, options.Identity) {
+				continue
+			}
+			if allowed.namespaces != nil && !matchesAny(allowed.namespaces, namespace) {
+				continue
+			}
+			if !allowed.validAt(now) {
+				continue
+			}
+			trusted = true
+			break
+		}
+		if !trusted {
+			return fmt.Errorf("signing key %s is not an allowed signer", ssh.FingerprintSHA256(signer))
+		}
+	} else {
+		expected, err := parseSSHPublicKey(publicKey)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(expected.Marshal(), signer.Marshal()) {
+			return fmt.Errorf("file was signed by key %s but public key is %s",
+				ssh.FingerprintSHA256(signer), ssh.FingerprintSHA256(expected))
+		}
+	}
+
+	var sig ssh.Signature
+	err = ssh.Unmarshal(blob.Signature, &sig)
+	if err != nil {
+		return fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	if sig.Format == ssh.KeyAlgoRSA {
+		return errors.New("SHA-1 RSA signatures are not accepted")
+	}
+	message, err := sshsigMessage(data, namespace, blob.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	return signer.Verify(message, &sig)
+}
@@ -0,0 +1,56 @@
+package detached
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSHSIGAllowedSignersValidity(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorized := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublic)))
+
+	data := []byte("licence bundle")
+	signature, err := Sign(private, data, SSHSIG, SignOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		options string
+		valid   bool
+	}{
+		"unbounded":      {"", true},
+		"within":         {`valid-after="20000101",valid-before="29991231235959Z" `, true},
+		"not yet valid":  {`valid-after="299912310000" `, false},
+		"expired":        {`valid-before="20000101Z" `, false},
+		"namespace only": {`namespaces="file" `, true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			allowed := "jane@example.com " + c.options + authorized + "\n"
+			err := Verify(data, signature, nil, VerifyOptions{AllowedSigners: []byte(allowed)})
+			if c.valid && err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if !c.valid && err == nil {
+				t.Fatal("signature verified outside the signer's validity interval")
+			}
+		})
+	}
+
+	_, err = parseAllowedSigners([]byte(`jane@example.com valid-after="2024-01-01" ` + authorized))
+	if err == nil {
+		t.Fatal("invalid valid-after time accepted")
+	}
+}
@@ -3,11 +3,13 @@ package fs
 import (
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/eslam-allam/file-signer/internal/constant"
 	"github.com/eslam-allam/file-signer/internal/slice"
 )
 
@@ -36,7 +38,20 @@ func Exists(path string) (bool, pathtype, error) {
 	return false, 0, err
 }
 
+// stdinRead records that standard input was consumed, since it can only be
+// read once per invocation.
+var stdinRead bool
+
+// SaveCreateIntermediate writes bytes to path, creating missing directories.
+// A path of "-" writes to standard output.
 func SaveCreateIntermediate(path string, bytes []byte, overwrite bool) error {
+	if path == constant.STANDARD_STREAM {
+		_, err := os.Stdout.Write(bytes)
+		if err != nil {
+			return fmt.Errorf("failed to write to standard output: %w", err)
+		}
+		return nil
+	}
 	exists, typ, err := Exists(path)
 	if err != nil {
 		return err
@@ -61,7 +76,19 @@ func SaveCreateIntermediate(path string, bytes []byte, overwrite bool) error {
 	return nil
 }
 
+// ReadFile reads the file at path. A path of "-" reads standard input.
 func ReadFile(path string) ([]byte, error) {
+	if path == constant.STANDARD_STREAM {
+		if stdinRead {
+			return nil, errors.New("standard input can only be read once")
+		}
+		stdinRead = true
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read standard input: %w", err)
+		}
+		return data, nil
+	}
 	exists, typ, err := Exists(path)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,51 @@
+package key
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type ExportFormat int
+
+const (
+	PEM ExportFormat = iota
+	MINISIGN
+	SSH
+)
+
+var ExportFormats = map[ExportFormat][]string{
+	PEM:      {"pem"},
+	MINISIGN: {"minisign"},
+	SSH:      {"ssh"},
+}
+
+var ExportFormatDescription = map[ExportFormat]string{
+	PEM:      "PKIX public key in a PEM block, as written by key generate.",
+	MINISIGN: "minisign public key file. Requires an Ed25519 key.",
+	SSH:      "OpenSSH authorized_keys line, usable in allowed_signers files.",
+}
+
+func ExportPublicKey(public crypto.PublicKey, format ExportFormat) ([]byte, error) {
+	switch format {
+	case PEM:
+		der, err := x509.MarshalPKIXPublicKey(public)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: PUBLIC_BLOCK, Bytes: der}), nil
+	case MINISIGN:
+		return MarshalMinisignPublicKey(public)
+	case SSH:
+		sshPublic, err := ssh.NewPublicKey(public)
+		if err != nil {
+			return nil, err
+		}
+		return ssh.MarshalAuthorizedKey(sshPublic), nil
+	default:
+		return nil, errors.New("invalid export format")
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -50,7 +52,11 @@ func generateRSAKey(bitsize uint) (crypto.PrivateKey, crypto.PublicKey, error) {
 }
 
 func generateEDKey() (crypto.PrivateKey, crypto.PublicKey, error) {
-	return ed25519.GenerateKey(rand.Reader)
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return private, public, nil
 }
 
 func generateECDSAKey() (crypto.PrivateKey, crypto.PublicKey, error) {
@@ -137,3 +143,12 @@ func GenerateKeyPair(typ KeyType, bitSize uint) (crypto.PrivateKey, crypto.Publi
 	}
 	return privateKey, publicKey, err
 }
+
+func Fingerprint(public crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,59 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"testing"
+)
+
+func TestGenerateKeyPair(t *testing.T) {
+	for typ, names := range KeyTypes {
+		t.Run(names[0], func(t *testing.T) {
+			private, public, err := GenerateKeyPair(typ, 2048)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signer, ok := private.(crypto.Signer)
+			if !ok {
+				t.Fatalf("private key %T is not a signer", private)
+			}
+			if !signer.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(public) {
+				t.Fatal("public key does not belong to the private key")
+			}
+
+			privateBytes, publicBytes, err := MarshalKeyPair(private, public)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = ParsePrivateKey(privateBytes)
+			if err != nil {
+				t.Fatalf("parse private key: %v", err)
+			}
+			_, err = ParsePublicKey(publicBytes)
+			if err != nil {
+				t.Fatalf("parse public key: %v", err)
+			}
+		})
+	}
+}
+
+// Ed25519 keys were once returned as (public, private), so the private key
+// file held the public key.
+func TestGenerateEDKeyOrder(t *testing.T) {
+	private, public, err := GenerateKeyPair(ED25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, ok := private.(ed25519.PrivateKey)
+	if !ok {
+		t.Fatalf("private key is %T, want ed25519.PrivateKey", private)
+	}
+	publicKey, ok := public.(ed25519.PublicKey)
+	if !ok {
+		t.Fatalf("public key is %T, want ed25519.PublicKey", public)
+	}
+	message := []byte("licence")
+	if !ed25519.Verify(publicKey, message, ed25519.Sign(privateKey, message)) {
+		t.Fatal("signature from the private key does not verify with the public key")
+	}
+}
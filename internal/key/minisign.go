@@ -0,0 +1,89 @@
+package key
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	MINISIGN_KEY_ID_SIZE = 8
+
+	minisignCommentPrefix string = "untrusted comment: "
+)
+
+var minisignAlgorithm = [2]byte{'E', 'd'}
+
+type MinisignPublicKey struct {
+	KeyId [MINISIGN_KEY_ID_SIZE]byte
+	Key   ed25519.PublicKey
+}
+
+// MinisignKeyId derives a minisign key ID from the key fingerprint so the
+// same key always exports with the same ID.
+func MinisignKeyId(public ed25519.PublicKey) ([MINISIGN_KEY_ID_SIZE]byte, error) {
+	var keyId [MINISIGN_KEY_ID_SIZE]byte
+	fingerprint, err := Fingerprint(public)
+	if err != nil {
+		return keyId, err
+	}
+	raw, err := hex.DecodeString(fingerprint[:2*MINISIGN_KEY_ID_SIZE])
+	if err != nil {
+		return keyId, err
+	}
+	copy(keyId[:], raw)
+	return keyId, nil
+}
+
+func FormatMinisignKeyId(keyId [MINISIGN_KEY_ID_SIZE]byte) string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(keyId[:]))
+}
+
+func MarshalMinisignPublicKey(public crypto.PublicKey) ([]byte, error) {
+	edPublic, ok := public.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("minisign requires an Ed25519 key")
+	}
+	keyId, err := MinisignKeyId(edPublic)
+	if err != nil {
+		return nil, err
+	}
+	raw := append(minisignAlgorithm[:], keyId[:]...)
+	raw = append(raw, edPublic...)
+	return []byte(minisignCommentPrefix + "minisign public key " + FormatMinisignKeyId(keyId) + "\n" +
+		base64.StdEncoding.EncodeToString(raw) + "\n"), nil
+}
+
+func ParseMinisignPublicKey(data []byte) (MinisignPublicKey, error) {
+	var encoded string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, minisignCommentPrefix) {
+			continue
+		}
+		encoded = line
+		break
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return MinisignPublicKey{}, fmt.Errorf("invalid minisign public key encoding: %w", err)
+	}
+	if len(raw) != 2+MINISIGN_KEY_ID_SIZE+ed25519.PublicKeySize {
+		return MinisignPublicKey{}, errors.New("invalid minisign public key length")
+	}
+	if !bytes.Equal(raw[:2], minisignAlgorithm[:]) {
+		return MinisignPublicKey{}, errors.New("unsupported minisign public key algorithm")
+	}
+	var public MinisignPublicKey
+	copy(public.KeyId[:], raw[2:2+MINISIGN_KEY_ID_SIZE])
+	public.Key = ed25519.PublicKey(raw[2+MINISIGN_KEY_ID_SIZE:])
+	return public, nil
+}
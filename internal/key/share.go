@@ -0,0 +1,143 @@
+package key
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/eslam-allam/file-signer/internal/shamir"
+)
+
+const (
+	SHARE_BLOCK string = "KEY SHARE"
+
+	shareIndexHeader       string = "Index"
+	shareThresholdHeader   string = "Threshold"
+	shareFingerprintHeader string = "Fingerprint"
+	shareChecksumHeader    string = "Checksum"
+)
+
+func shareChecksum(index byte, data []byte) string {
+	sum := sha256.Sum256(append([]byte{index}, data...))
+	return hex.EncodeToString(sum[:4])
+}
+
+// SplitPrivateKey splits the PKCS #8 encoding of private into n PEM encoded
+// shares, any threshold of which can rebuild it with CombinePrivateKey.
+func SplitPrivateKey(private crypto.PrivateKey, n, threshold int) ([][]byte, error) {
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key is not a signer")
+	}
+	fingerprint, err := Fingerprint(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := shamir.Split(der, n, threshold)
+	clear(der)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := make([][]byte, len(shares))
+	for i, share := range shares {
+		encoded[i] = pem.EncodeToMemory(&pem.Block{
+			Type: SHARE_BLOCK,
+			Headers: map[string]string{
+				shareIndexHeader:       strconv.Itoa(int(share.Index)),
+				shareThresholdHeader:   strconv.Itoa(threshold),
+				shareFingerprintHeader: fingerprint,
+				shareChecksumHeader:    shareChecksum(share.Index, share.Data),
+			},
+			Bytes: share.Data,
+		})
+	}
+	return encoded, nil
+}
+
+type parsedShare struct {
+	shamir.Share
+	threshold   int
+	fingerprint string
+}
+
+func parseShare(data []byte) (parsedShare, error) {
+	block, err := findBlock(data, SHARE_BLOCK)
+	if err != nil {
+		return parsedShare{}, err
+	}
+	index, err := strconv.Atoi(block.Headers[shareIndexHeader])
+	if err != nil || index < 1 || index > 255 {
+		return parsedShare{}, fmt.Errorf("invalid share index '%s'", block.Headers[shareIndexHeader])
+	}
+	threshold, err := strconv.Atoi(block.Headers[shareThresholdHeader])
+	if err != nil || threshold < 2 {
+		return parsedShare{}, fmt.Errorf("invalid share threshold '%s'", block.Headers[shareThresholdHeader])
+	}
+	checksum := shareChecksum(byte(index), block.Bytes)
+	if subtle.ConstantTimeCompare([]byte(checksum), []byte(block.Headers[shareChecksumHeader])) != 1 {
+		return parsedShare{}, fmt.Errorf("share %d is corrupted: checksum mismatch", index)
+	}
+	return parsedShare{
+		Share:       shamir.Share{Index: byte(index), Data: block.Bytes},
+		threshold:   threshold,
+		fingerprint: block.Headers[shareFingerprintHeader],
+	}, nil
+}
+
+// CombinePrivateKey rebuilds a private key from PEM encoded shares and checks
+// it against the fingerprint recorded in them.
+func CombinePrivateKey(encoded [][]byte) (crypto.PrivateKey, error) {
+	if len(encoded) == 0 {
+		return nil, errors.New("no shares supplied")
+	}
+	shares := make([]shamir.Share, len(encoded))
+	var first parsedShare
+	for i, data := range encoded {
+		share, err := parseShare(data)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			first = share
+		} else if share.fingerprint != first.fingerprint || share.threshold != first.threshold {
+			return nil, fmt.Errorf("share %d belongs to a different key split", share.Index)
+		}
+		shares[i] = share.Share
+	}
+	if len(shares) < first.threshold {
+		return nil, fmt.Errorf("%d shares supplied but %d are required", len(shares), first.threshold)
+	}
+
+	der, err := shamir.Combine(shares)
+	if err != nil {
+		return nil, err
+	}
+	private, err := x509.ParsePKCS8PrivateKey(der)
+	clear(der)
+	if err != nil {
+		return nil, fmt.Errorf("recovered key is invalid: %w", err)
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("recovered key is not a signer")
+	}
+	fingerprint, err := Fingerprint(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	if fingerprint != first.fingerprint {
+		return nil, fmt.Errorf("recovered key fingerprint '%s' does not match '%s'", fingerprint, first.fingerprint)
+	}
+	return private, nil
+}
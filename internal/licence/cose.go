@@ -0,0 +1,265 @@
+package licence
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/sign"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// COSE header labels and algorithm identifiers from RFC 9052, RFC 9053 and RFC 8812.
+const (
+	coseHeaderAlgorithm = 1
+	coseHeaderKeyId     = 4
+
+	coseSign1Tag = 18
+
+	coseES256 = -7
+	coseES384 = -35
+	coseES512 = -36
+	coseEdDSA = -8
+	coseRS256 = -257
+)
+
+// coseClaims uses CWT claim keys (RFC 8392) for the registered claims so
+// constrained verifiers can read them without a text key lookup.
+type coseClaims struct {
+	Issuer      string   `cbor:"1,keyasint"`
+	ExpiresAt   int64    `cbor:"4,keyasint"`
+	IssuedAt    int64    `cbor:"6,keyasint"`
+	LicenceKey  []byte   `cbor:"7,keyasint"`
+	Name        string   `cbor:"name"`
+	Email       string   `cbor:"email"`
+	Product     string   `cbor:"product"`
+	Version     string   `cbor:"version"`
+	Tier        string   `cbor:"tier,omitempty"`
+	Features    []string `cbor:"features,omitempty"`
+	Predecessor string   `cbor:"predecessor,omitempty"`
+}
+
+type coseSign1 struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   []byte
+	Unprotected map[int]any
+	Payload     []byte
+	Signature   []byte
+}
+
+type coseSigStructure struct {
+	_           struct{} `cbor:",toarray"`
+	Context     string
+	Protected   []byte
+	ExternalAAD []byte
+	Payload     []byte
+}
+
+var coseEncMode, _ = cbor.CoreDetEncOptions().EncMode()
+
+func coseAlgorithm(publicKey crypto.PublicKey) (int, crypto.Hash, error) {
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		return coseRS256, crypto.SHA256, nil
+	case *ecdsa.PublicKey:
+		switch publicKey.Curve {
+		case elliptic.P256():
+			return coseES256, crypto.SHA256, nil
+		case elliptic.P384():
+			return coseES384, crypto.SHA384, nil
+		case elliptic.P521():
+			return coseES512, crypto.SHA512, nil
+		}
+		return 0, 0, errors.New("unsupported elliptic curve")
+	case ed25519.PublicKey:
+		return coseEdDSA, 0, nil
+	default:
+		return 0, 0, sign.ErrInvalidPublicKey
+	}
+}
+
+func coseToBeSigned(protected, payload []byte) ([]byte, error) {
+	return coseEncMode.Marshal(coseSigStructure{
+		Context:     "Signature1",
+		Protected:   protected,
+		ExternalAAD: []byte{},
+		Payload:     payload,
+	})
+}
+
+func signLicenceCOSE(signer crypto.Signer, licence Licence, options SignOptions) ([]byte, error) {
+	licence, _, err := prepareLicence(licence)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := licenceToClaims(licence)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, hash, err := coseAlgorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	unprotected := map[int]any{}
+	if !options.OmitKeyId {
+		keyId, err := key.Fingerprint(signer.Public())
+		if err != nil {
+			return nil, err
+		}
+		unprotected[coseHeaderKeyId], err = hex.DecodeString(keyId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	payload, err := coseEncMode.Marshal(coseClaims{
+		Issuer:      claims.Issuer,
+		ExpiresAt:   claims.ExpiresAt,
+		IssuedAt:    claims.IssuedAt,
+		LicenceKey:  []byte(claims.Id),
+		Name:        claims.Name,
+		Email:       claims.Email,
+		Product:     claims.Product,
+		Version:     claims.Version,
+		Tier:        claims.Tier,
+		Features:    claims.Features,
+		Predecessor: claims.Predecessor,
+	})
+	if err != nil {
+		return nil, err
+	}
+	protected, err := coseEncMode.Marshal(map[int]int{coseHeaderAlgorithm: algorithm})
+	if err != nil {
+		return nil, err
+	}
+	toBeSigned, err := coseToBeSigned(protected, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := sign.SignMessageHash(signer, toBeSigned, hash)
+	if err != nil {
+		return nil, err
+	}
+	if publicKey, ok := signer.Public().(*ecdsa.PublicKey); ok {
+		signature, err = sign.ECDSAToRaw(signature, publicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return coseEncMode.Marshal(cbor.Tag{
+		Number: coseSign1Tag,
+		Content: coseSign1{
+			Protected:   protected,
+			Unprotected: unprotected,
+			Payload:     payload,
+			Signature:   signature,
+		},
+	})
+}
+
+// parseCOSESign1 decodes a tagged or untagged COSE_Sign1 message.
+func parseCOSESign1(data []byte) (coseSign1, error) {
+	var message coseSign1
+	var tag cbor.RawTag
+	err := cbor.Unmarshal(data, &tag)
+	if err == nil {
+		if tag.Number != coseSign1Tag {
+			return coseSign1{}, fmt.Errorf("unexpected CBOR tag %d, expected COSE_Sign1", tag.Number)
+		}
+		data = tag.Content
+	}
+	err = cbor.Unmarshal(data, &message)
+	if err != nil {
+		return coseSign1{}, fmt.Errorf("invalid COSE_Sign1 structure: %w", err)
+	}
+	return message, nil
+}
+
+// verifyCOSESign1 checks that message was signed by publicKey using the
+// algorithm named in its protected header.
+func verifyCOSESign1(message coseSign1, publicKey crypto.PublicKey) error {
+	var protected map[int]any
+	err := cbor.Unmarshal(message.Protected, &protected)
+	if err != nil {
+		return fmt.Errorf("invalid COSE protected header: %w", err)
+	}
+	algorithm, hash, err := coseAlgorithm(publicKey)
+	if err != nil {
+		return err
+	}
+	if signedAlgorithm, ok := protected[coseHeaderAlgorithm].(int64); !ok || signedAlgorithm != int64(algorithm) {
+		return fmt.Errorf("%w: licence was signed using COSE algorithm %v but public key uses %d", ErrKeyMismatch, protected[coseHeaderAlgorithm], algorithm)
+	}
+
+	signature := message.Signature
+	if publicKey, ok := publicKey.(*ecdsa.PublicKey); ok {
+		signature, err = sign.ECDSAFromRaw(signature, publicKey)
+		if err != nil {
+			return err
+		}
+	}
+	toBeSigned, err := coseToBeSigned(message.Protected, message.Payload)
+	if err != nil {
+		return err
+	}
+	return sign.VerifySignatureHash(signature, toBeSigned, publicKey, hash)
+}
+
+func (m coseSign1) claims() (Licence, error) {
+	var claims coseClaims
+	err := cbor.Unmarshal(m.Payload, &claims)
+	if err != nil {
+		return Licence{}, fmt.Errorf("invalid COSE payload: %w", err)
+	}
+	return claimsToLicence(jwsClaims{
+		Id:          string(claims.LicenceKey),
+		Issuer:      claims.Issuer,
+		IssuedAt:    claims.IssuedAt,
+		ExpiresAt:   claims.ExpiresAt,
+		Name:        claims.Name,
+		Email:       claims.Email,
+		Product:     claims.Product,
+		Version:     claims.Version,
+		Tier:        claims.Tier,
+		Features:    claims.Features,
+		Predecessor: claims.Predecessor,
+	}), nil
+}
+
+func (m coseSign1) signingKeyId() (string, error) {
+	signedKeyId, _ := m.Unprotected[coseHeaderKeyId].([]byte)
+	return hex.EncodeToString(signedKeyId), nil
+}
+
+func (m coseSign1) primarySignature() ([]byte, error) {
+	return m.Signature, nil
+}
+
+func verifyLicenceCOSE(data []byte, publicKey crypto.PublicKey) (Licence, error) {
+	message, err := parseCOSESign1(data)
+	if err != nil {
+		return Licence{}, err
+	}
+	if signedKeyId, ok := message.Unprotected[coseHeaderKeyId].([]byte); ok {
+		keyId, err := key.Fingerprint(publicKey)
+		if err != nil {
+			return Licence{}, err
+		}
+		if hex.EncodeToString(signedKeyId) != keyId {
+			return Licence{}, fmt.Errorf("%w: licence was signed by key '%x' but public key is '%s'", ErrKeyMismatch, signedKeyId, keyId)
+		}
+	}
+	err = verifyCOSESign1(message, publicKey)
+	if err != nil {
+		return Licence{}, err
+	}
+	return message.claims()
+}
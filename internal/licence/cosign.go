@@ -0,0 +1,151 @@
+package licence
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/sign"
+	"github.com/eslam-allam/file-signer/internal/slice"
+)
+
+// Cosignature is an additional signature over the same licence payload as
+// SignedLicence.Signature, made by a different key.
+type Cosignature struct {
+	KeyId     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	Signature string `json:"signature"`
+}
+
+// TrustPolicy requires Threshold distinct keys out of Keys to have signed a licence.
+type TrustPolicy struct {
+	Threshold int
+	Keys      []crypto.PublicKey
+}
+
+type trustPolicyFile struct {
+	Threshold int      `json:"threshold"`
+	Keys      []string `json:"keys"`
+}
+
+// LoadTrustPolicy reads a JSON trust policy of the form
+// {"threshold": 2, "keys": ["officer1.pem", "officer2.pem"]}.
+// Key paths are relative to the policy file.
+func LoadTrustPolicy(path string) (TrustPolicy, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return TrustPolicy{}, err
+	}
+	var policyFile trustPolicyFile
+	err = json.Unmarshal(data, &policyFile)
+	if err != nil {
+		return TrustPolicy{}, fmt.Errorf("invalid trust policy '%s': %w", path, err)
+	}
+	if policyFile.Threshold < 1 {
+		return TrustPolicy{}, errors.New("trust policy threshold must be at least 1")
+	}
+	if policyFile.Threshold > len(policyFile.Keys) {
+		return TrustPolicy{}, fmt.Errorf("trust policy threshold %d exceeds the %d trusted keys", policyFile.Threshold, len(policyFile.Keys))
+	}
+
+	keys, err := slice.MapWithErr(policyFile.Keys, func(keyPath string) (crypto.PublicKey, error) {
+		if !filepath.IsAbs(keyPath) {
+			keyPath = filepath.Join(filepath.Dir(path), keyPath)
+		}
+		keyBytes, err := fs.ReadFile(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		return key.ParsePublicKey(keyBytes)
+	})
+	if err != nil {
+		return TrustPolicy{}, err
+	}
+	fingerprints := make(map[string]bool, len(keys))
+	for _, publicKey := range keys {
+		fingerprint, err := key.Fingerprint(publicKey)
+		if err != nil {
+			return TrustPolicy{}, err
+		}
+		if fingerprints[fingerprint] {
+			return TrustPolicy{}, fmt.Errorf("trust policy lists key '%s' more than once", fingerprint)
+		}
+		fingerprints[fingerprint] = true
+	}
+	return TrustPolicy{Threshold: policyFile.Threshold, Keys: keys}, nil
+}
+
+func CosignLicence(signer crypto.Signer, signed SignedLicence) (SignedLicence, error) {
+	if signed.Signature == "" {
+		return SignedLicence{}, errors.New("licence must be signed before it can be cosigned")
+	}
+	keyId, err := key.Fingerprint(signer.Public())
+	if err != nil {
+		return SignedLicence{}, err
+	}
+	if VerifyLicenceSignature(signed, signer.Public()) == nil ||
+		slice.AnyMatch(signed.Cosignatures, func(c Cosignature) bool { return c.KeyId == keyId }) {
+		return SignedLicence{}, fmt.Errorf("licence is already signed by key '%s'", keyId)
+	}
+
+	licenceData, err := json.Marshal(signed.Licence)
+	if err != nil {
+		return SignedLicence{}, err
+	}
+	signature, err := sign.SignMessage(signer, licenceData)
+	if err != nil {
+		return SignedLicence{}, err
+	}
+	algorithm, err := sign.Algorithm(signer.Public())
+	if err != nil {
+		return SignedLicence{}, err
+	}
+	signed.Cosignatures = append(signed.Cosignatures, Cosignature{
+		KeyId:     keyId,
+		Algorithm: algorithm,
+		Signature: base64.StdEncoding.EncodeToString(signature),
+	})
+	return signed, nil
+}
+
+// VerifyThreshold checks that at least policy.Threshold distinct keys of the
+// policy produced a valid signature or cosignature over the licence.
+func VerifyThreshold(signed SignedLicence, policy TrustPolicy) error {
+	licenceData, err := json.Marshal(signed.Licence)
+	if err != nil {
+		return err
+	}
+	signatures := []string{signed.Signature}
+	for _, cosignature := range signed.Cosignatures {
+		signatures = append(signatures, cosignature.Signature)
+	}
+
+	valid := 0
+	counted := make(map[string]bool, len(policy.Keys))
+	for _, publicKey := range policy.Keys {
+		// A key listed twice still counts once.
+		fingerprint, err := key.Fingerprint(publicKey)
+		if err != nil {
+			return err
+		}
+		if counted[fingerprint] {
+			continue
+		}
+		counted[fingerprint] = true
+		if slice.AnyMatch(signatures, func(encoded string) bool {
+			signature, err := base64.StdEncoding.DecodeString(encoded)
+			return err == nil && sign.VerifySignature(signature, licenceData, publicKey) == nil
+		}) {
+			valid++
+		}
+	}
+	if valid < policy.Threshold {
+		return fmt.Errorf("%w: licence has %d valid signatures from trusted keys, %d required", sign.ErrInvalidSignature, valid, policy.Threshold)
+	}
+	return nil
+}
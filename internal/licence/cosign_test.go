@@ -0,0 +1,103 @@
+package licence
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/eslam-allam/file-signer/internal/sign"
+)
+
+func signedBy(t *testing.T, signers ...crypto.Signer) SignedLicence {
+	t.Helper()
+	data, err := SignLicenceAs(signers[0], testLicence(), JSON, SignOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signed SignedLicence
+	err = json.Unmarshal(data, &signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, signer := range signers[1:] {
+		signed, err = CosignLicence(signer, signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return signed
+}
+
+func TestVerifyThreshold(t *testing.T) {
+	var a, b, c, untrusted crypto.Signer
+	for _, s := range []*crypto.Signer{&a, &b, &untrusted} {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		*s = private
+	}
+	c, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusted := []crypto.PublicKey{a.Public(), b.Public(), c.Public()}
+
+	duplicated := signedBy(t, a, b)
+	duplicated.Cosignatures = append(duplicated.Cosignatures, duplicated.Cosignatures[0])
+	corrupted := signedBy(t, a, b)
+	corrupted.Cosignatures[0].Signature = corrupted.Signature
+	garbage := signedBy(t, a, b)
+	garbage.Cosignatures[0].Signature = "not base64!"
+	tampered := signedBy(t, a, b, c)
+	tampered.Licence.Tier = "enterprise"
+
+	tests := []struct {
+		name      string
+		signed    SignedLicence
+		keys      []crypto.PublicKey
+		threshold int
+		valid     bool
+	}{
+		{"one of three", signedBy(t, a), trusted, 1, true},
+		{"one signature for two of three", signedBy(t, a), trusted, 2, false},
+		{"two of three", signedBy(t, a, b), trusted, 2, true},
+		{"two signatures for three of three", signedBy(t, a, b), trusted, 3, false},
+		{"three of three", signedBy(t, a, b, c), trusted, 3, true},
+		{"cosignatures in any order", signedBy(t, c, a), trusted, 2, true},
+		{"untrusted cosigner is not counted", signedBy(t, a, untrusted), trusted, 2, false},
+		{"untrusted primary signer", signedBy(t, untrusted, a, b), trusted, 2, true},
+		{"duplicate cosignature counted once", duplicated, trusted, 3, false},
+		{"duplicate trusted key counted once", signedBy(t, a, b), []crypto.PublicKey{a.Public(), a.Public(), b.Public()}, 3, false},
+		{"cosignature copied from another key", corrupted, trusted, 2, false},
+		{"undecodable cosignature", garbage, trusted, 2, false},
+		{"tampered licence", tampered, trusted, 1, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifyThreshold(test.signed, TrustPolicy{Threshold: test.threshold, Keys: test.keys})
+			if test.valid && err != nil {
+				t.Fatalf("VerifyThreshold: %v", err)
+			}
+			if !test.valid && !errors.Is(err, sign.ErrInvalidSignature) {
+				t.Fatalf("VerifyThreshold = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestCosignTwice(t *testing.T) {
+	_, a, _ := ed25519.GenerateKey(rand.Reader)
+	_, b, _ := ed25519.GenerateKey(rand.Reader)
+	signed := signedBy(t, a, b)
+	for _, signer := range []crypto.Signer{a, b} {
+		if _, err := CosignLicence(signer, signed); err == nil {
+			t.Fatal("licence was cosigned twice by the same key")
+		}
+	}
+}
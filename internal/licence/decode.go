@@ -0,0 +1,58 @@
+package licence
+
+import "errors"
+
+// parsedLicence is a signed licence split into its parts by the parser of
+// its format. Verification, Decode and SignatureFingerprint all read
+// licences through these parsers.
+type parsedLicence interface {
+	// claims returns the licence without verifying its signature.
+	claims() (Licence, error)
+	// signingKeyId returns the fingerprint of the signing key named by the
+	// licence, or "" when it names none.
+	signingKeyId() (string, error)
+	// primarySignature returns the raw first signature.
+	primarySignature() ([]byte, error)
+}
+
+func parseLicence(data []byte) (parsedLicence, error) {
+	format, err := DetectFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case JSON:
+		return parseJSON(data)
+	case PEM:
+		return parsePEM(data)
+	case JWS:
+		return parseJWS(data)
+	case JWS_JSON:
+		return parseJWSJSON(data)
+	case COSE:
+		return parseCOSESign1(data)
+	case PASETO:
+		return parsePASETO(data)
+	default:
+		return nil, errors.New("invalid licence format")
+	}
+}
+
+// Decode reads a signed licence in any format without verifying its
+// signature. It also returns the fingerprint of the signing key when the
+// licence names one.
+func Decode(data []byte) (licence Licence, keyId string, err error) {
+	parsed, err := parseLicence(data)
+	if err != nil {
+		return Licence{}, "", err
+	}
+	licence, err = parsed.claims()
+	if err != nil {
+		return Licence{}, "", err
+	}
+	keyId, err = parsed.signingKeyId()
+	if err != nil {
+		return Licence{}, "", err
+	}
+	return licence, keyId, nil
+}
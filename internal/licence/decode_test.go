@@ -0,0 +1,70 @@
+package licence
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+)
+
+// TestDecodeMatchesVerify checks that decoding, fingerprinting and verifying
+// a signed licence read it the same way in every format.
+func TestDecodeMatchesVerify(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyId, err := key.Fingerprint(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for format, names := range Formats {
+		t.Run(names[0], func(t *testing.T) {
+			signed, err := SignLicenceAs(private, testLicence(), format, SignOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			verified, err := VerifyLicence(signed, public, VerifyOptions{Product: testLicence().Product})
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+
+			decoded, decodedKeyId, err := Decode(signed)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, verified) {
+				t.Fatalf("decoded licence = %+v, verified licence = %+v", decoded, verified)
+			}
+			// Plain JSON licences do not name their signing key.
+			if format != JSON && decodedKeyId != keyId {
+				t.Fatalf("decoded key ID = %q, want %q", decodedKeyId, keyId)
+			}
+
+			sig, err := signature(signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fingerprint, err := SignatureFingerprint(signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sum := sha256.Sum256(sig)
+			if fingerprint != hex.EncodeToString(sum[:]) || len(sig) != ed25519.SignatureSize {
+				t.Fatalf("signature fingerprint %s does not match the %d byte signature", fingerprint, len(sig))
+			}
+		})
+	}
+}
+
+func TestSignatureUnsigned(t *testing.T) {
+	_, err := SignatureFingerprint([]byte(`{"licence_key": "5b7c2a3e-9f14-4d2a-8c1e-2f6b9a0d4e71", "signature": ""}`))
+	if err != ErrUnsigned {
+		t.Fatalf("fingerprint of an unsigned licence: %v, want ErrUnsigned", err)
+	}
+}
@@ -0,0 +1,81 @@
+package licence
+
+const (
+	CHANGE_ADDED    = "added"
+	CHANGE_REMOVED  = "removed"
+	CHANGE_MODIFIED = "modified"
+)
+
+// Change is one field that differs between two licences. Features are named
+// features.<name> and their values are the features as listed, e.g. seats=5,
+// so that a feature without a value is not reported with an empty one.
+type Change struct {
+	Field string `json:"field"`
+	Kind  string `json:"kind"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+func diffValue(field, old, new string) (Change, bool) {
+	switch {
+	case old == new:
+		return Change{}, false
+	case old == "":
+		return Change{Field: field, Kind: CHANGE_ADDED, New: new}, true
+	case new == "":
+		return Change{Field: field, Kind: CHANGE_REMOVED, Old: old}, true
+	default:
+		return Change{Field: field, Kind: CHANGE_MODIFIED, Old: old, New: new}, true
+	}
+}
+
+// featuresByName indexes features by name, keeping the order they were
+// listed in.
+func featuresByName(features []string) (names []string, byName map[string]string) {
+	byName = make(map[string]string, len(features))
+	for _, feature := range features {
+		name, _ := ParseFeature(feature)
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = feature
+	}
+	return names, byName
+}
+
+// Diff compares two licences field by field. Features are compared one by
+// one, and a feature claim such as seats=5 is reported as modified when only
+// its value changed.
+func Diff(a, b Licence) []Change {
+	var changes []Change
+	for _, field := range Fields {
+		if field == "features" {
+			continue
+		}
+		if change, ok := diffValue(field, GetField(a, field), GetField(b, field)); ok {
+			changes = append(changes, change)
+		}
+	}
+	if change, ok := diffValue("predecessor", a.Predecessor, b.Predecessor); ok {
+		changes = append(changes, change)
+	}
+
+	oldNames, oldFeatures := featuresByName(a.Features)
+	newNames, newFeatures := featuresByName(b.Features)
+	for _, name := range oldNames {
+		field := "features." + name
+		feature, ok := newFeatures[name]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Field: field, Kind: CHANGE_REMOVED, Old: oldFeatures[name]})
+		case feature != oldFeatures[name]:
+			changes = append(changes, Change{Field: field, Kind: CHANGE_MODIFIED, Old: oldFeatures[name], New: feature})
+		}
+	}
+	for _, name := range newNames {
+		if _, ok := oldFeatures[name]; !ok {
+			changes = append(changes, Change{Field: "features." + name, Kind: CHANGE_ADDED, New: newFeatures[name]})
+		}
+	}
+	return changes
+}
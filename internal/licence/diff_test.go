@@ -0,0 +1,111 @@
+package licence
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*Licence)
+		want   []Change
+	}{
+		{"unchanged", func(*Licence) {}, nil},
+		{"modified field", func(l *Licence) { l.ExpiryDate = "2026-01-01" },
+			[]Change{{Field: "expiry_date", Kind: CHANGE_MODIFIED, Old: "2025-01-01", New: "2026-01-01"}}},
+		{"removed field", func(l *Licence) { l.Tier = "" },
+			[]Change{{Field: "tier", Kind: CHANGE_REMOVED, Old: "pro"}}},
+		{"feature value modified", func(l *Licence) { l.Features = []string{"export", "seats=10"} },
+			[]Change{{Field: "features.seats", Kind: CHANGE_MODIFIED, Old: "seats=5", New: "seats=10"}}},
+		{"feature value removed", func(l *Licence) { l.Features = []string{"export", "seats"} },
+			[]Change{{Field: "features.seats", Kind: CHANGE_MODIFIED, Old: "seats=5", New: "seats"}}},
+		{"bare feature removed", func(l *Licence) { l.Features = []string{"seats=5"} },
+			[]Change{{Field: "features.export", Kind: CHANGE_REMOVED, Old: "export"}}},
+		{"feature added", func(l *Licence) { l.Features = append(l.Features, "sso") },
+			[]Change{{Field: "features.sso", Kind: CHANGE_ADDED, New: "sso"}}},
+		{"features reordered", func(l *Licence) { l.Features = []string{"seats=5", "export"} }, nil},
+		{"all features removed", func(l *Licence) { l.Features = nil }, []Change{
+			{Field: "features.export", Kind: CHANGE_REMOVED, Old: "export"},
+			{Field: "features.seats", Kind: CHANGE_REMOVED, Old: "seats=5"},
+		}},
+		{"fields in order", func(l *Licence) {
+			l.Tier = "enterprise"
+			l.Name = "Jane Roe"
+			l.Features = []string{"audit"}
+		}, []Change{
+			{Field: "name", Kind: CHANGE_MODIFIED, Old: "Jane Doe", New: "Jane Roe"},
+			{Field: "tier", Kind: CHANGE_MODIFIED, Old: "pro", New: "enterprise"},
+			{Field: "features.export", Kind: CHANGE_REMOVED, Old: "export"},
+			{Field: "features.seats", Kind: CHANGE_REMOVED, Old: "seats=5"},
+			{Field: "features.audit", Kind: CHANGE_ADDED, New: "audit"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := testLicence()
+			b := testLicence()
+			test.change(&b)
+			if got := Diff(a, b); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("Diff = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDiffSuccessor(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := testLicence()
+	signed, err := SignLicenceAs(private, previous, JSON, SignOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := Successor(previous, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err = Extend(next, "1y")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	predecessor, err := Predecessor(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Predecessor != predecessor || next.LicenceKey != previous.LicenceKey {
+		t.Fatalf("successor = %+v, want licence key %s and predecessor %s", next, previous.LicenceKey, predecessor)
+	}
+	want := []Change{
+		{Field: "issue_date", Kind: CHANGE_MODIFIED, Old: previous.IssueDate, New: next.IssueDate},
+		{Field: "expiry_date", Kind: CHANGE_MODIFIED, Old: previous.ExpiryDate, New: next.ExpiryDate},
+		{Field: "predecessor", Kind: CHANGE_ADDED, New: predecessor},
+	}
+	if got := Diff(previous, next); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff = %+v, want %+v", got, want)
+	}
+
+	// A successor of a different signed licence names another predecessor.
+	other := previous
+	other.Tier = "enterprise"
+	otherSigned, err := SignLicenceAs(private, other, JSON, SignOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherNext, err := Successor(other, otherSigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Diff(next, otherNext); len(got) == 0 || got[len(got)-1].Field != "predecessor" || got[len(got)-1].Kind != CHANGE_MODIFIED {
+		t.Fatalf("Diff = %+v, want the predecessor modified", got)
+	}
+	_, err = Successor(previous, []byte("not a licence"))
+	if err == nil {
+		t.Fatal("Successor accepted an unsigned licence")
+	}
+}
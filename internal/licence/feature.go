@@ -0,0 +1,62 @@
+package licence
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Features are written either as a bare name, e.g. export, or as a claim
+// carrying a value, e.g. seats=5. A licence lists each feature name once.
+const featurePattern string = `^[^=,\s]+(=[^,]+)?$`
+
+// ParseFeature splits feature into its name and value. The value is empty
+// for a bare feature name.
+func ParseFeature(feature string) (name, value string) {
+	name, value, _ = strings.Cut(feature, "=")
+	return name, value
+}
+
+// Feature returns the value of the named feature and whether the licence
+// has it.
+func (l Licence) Feature(name string) (string, bool) {
+	for _, feature := range l.Features {
+		if featureName, value := ParseFeature(feature); featureName == name {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// HasFeature reports whether the licence satisfies requirement. A bare name
+// is met by the feature with any value, name=value only by that value.
+func (l Licence) HasFeature(requirement string) bool {
+	name, required := ParseFeature(requirement)
+	value, ok := l.Feature(name)
+	return ok && (required == "" || value == required)
+}
+
+// addFeature adds feature to l unless a feature of the same name is already
+// listed, whose value is kept.
+func addFeature(l Licence, feature string) Licence {
+	name, _ := ParseFeature(feature)
+	if _, ok := l.Feature(name); !ok {
+		l.Features = append(l.Features, feature)
+	}
+	return l
+}
+
+func validateFeatures(features []string) error {
+	seen := make(map[string]bool, len(features))
+	for _, feature := range features {
+		err := validateValue(*licenceSchema.Properties.Features.Items, feature)
+		if err != nil {
+			return err
+		}
+		name, _ := ParseFeature(feature)
+		if seen[name] {
+			return fmt.Errorf("feature '%s' is listed more than once", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package licence
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHasFeature(t *testing.T) {
+	l := Licence{Features: []string{"export", "seats=5"}}
+	tests := []struct {
+		requirement string
+		want        bool
+	}{
+		{"export", true},
+		{"seats", true},
+		{"seats=5", true},
+		{"seats=10", false},
+		{"export=yes", false},
+		{"sso", false},
+	}
+	for _, test := range tests {
+		if got := l.HasFeature(test.requirement); got != test.want {
+			t.Errorf("HasFeature(%q) = %v, want %v", test.requirement, got, test.want)
+		}
+	}
+}
+
+func TestValidateFeatures(t *testing.T) {
+	valid := [][]string{nil, {"export"}, {"seats=5", "plan=Gold Plus"}}
+	for _, features := range valid {
+		if err := validateFeatures(features); err != nil {
+			t.Errorf("validateFeatures(%q): %v", features, err)
+		}
+	}
+	invalid := [][]string{{""}, {"=5"}, {"seats="}, {"sso extra"}, {"seats=5", "seats=10"}, {"export", "export"}}
+	for _, features := range invalid {
+		if err := validateFeatures(features); err == nil {
+			t.Errorf("validateFeatures(%q) accepted invalid features", features)
+		}
+	}
+}
+
+func TestPolicyFeatures(t *testing.T) {
+	policy := IssuancePolicy{
+		Products: map[string]ProductPolicy{
+			"editor": {Tiers: map[string][]string{"pro": {"sso", "seats"}, "site": {"seats=unlimited"}}},
+		},
+		Profiles: map[string]Profile{
+			"pro": {Features: []string{"sso", "seats=5"}},
+		},
+	}
+
+	l, err := policy.ApplyProfile(Licence{Product: "editor", Tier: "pro", Features: []string{"seats=20"}}, "pro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"seats=20", "sso"}; !slices.Equal(l.Features, want) {
+		t.Fatalf("profile features = %q, want %q", l.Features, want)
+	}
+	if err = policy.Check(l); err != nil {
+		t.Fatalf("pro licence with seats=20: %v", err)
+	}
+
+	l.Tier = "site"
+	if err = policy.Check(l); err == nil {
+		t.Fatal("site tier accepted seats=20 instead of seats=unlimited")
+	}
+}
@@ -0,0 +1,99 @@
+package licence
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SchemaField describes one licence field as declared in the licence schema.
+type SchemaField struct {
+	Name        string
+	Description string
+	Required    bool
+	property    schemaProperty
+}
+
+// SchemaFields lists the licence schema fields in declaration order,
+// leaving out those filled by the tool itself.
+func SchemaFields() []SchemaField {
+	properties := reflect.ValueOf(licenceSchema.Properties)
+	fields := make([]SchemaField, 0, properties.NumField())
+	for i := 0; i < properties.NumField(); i++ {
+		name, _, _ := strings.Cut(properties.Type().Field(i).Tag.Get("json"), ",")
+		if name == "predecessor" {
+			continue
+		}
+		property := properties.Field(i).Interface().(schemaProperty)
+		fields = append(fields, SchemaField{
+			Name:        name,
+			Description: property.Description,
+			Required:    slices.Contains(licenceSchema.Required, name),
+			property:    property,
+		})
+	}
+	return fields
+}
+
+func validateValue(property schemaProperty, value string) error {
+	if len(value) < property.MinLength {
+		return fmt.Errorf("must be at least %d characters", property.MinLength)
+	}
+	switch property.Format {
+	case "email":
+		address, err := mail.ParseAddress(value)
+		if err != nil || address.Address != value {
+			return fmt.Errorf("'%s' is not an email address", value)
+		}
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a yyyy-mm-dd date", value)
+		}
+	}
+	if property.Pattern != "" && !regexp.MustCompile(property.Pattern).MatchString(value) {
+		return fmt.Errorf("'%s' does not match %s", value, property.Pattern)
+	}
+	return nil
+}
+
+// Validate checks value against the field's schema. Features, the only
+// array field, take a comma separated list.
+func (f SchemaField) Validate(value string) error {
+	if value == "" {
+		if f.Required {
+			return fmt.Errorf("%s is required", f.Name)
+		}
+		return nil
+	}
+	if f.property.Items == nil {
+		return validateValue(f.property, value)
+	}
+	return validateFeatures(splitFeatures(value))
+}
+
+// IsDate reports whether the field holds a yyyy-mm-dd date.
+func (f SchemaField) IsDate() bool {
+	return f.property.Format == "date"
+}
+
+// ResolveDate expands the date helpers "today" and "+<period>" (e.g. +1y,
+// +30d) relative to base. Other values are returned unchanged.
+func ResolveDate(value string, base time.Time) (string, error) {
+	switch {
+	case value == "today":
+		return time.Now().Format(time.DateOnly), nil
+	case strings.HasPrefix(value, "+"):
+		date, err := addPeriod(base, strings.TrimPrefix(value, "+"))
+		if err != nil {
+			return "", err
+		}
+		return date.Format(time.DateOnly), nil
+	default:
+		return value, nil
+	}
+}
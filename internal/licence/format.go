@@ -0,0 +1,194 @@
+package licence
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/eslam-allam/file-signer/internal/timestamp"
+)
+
+type Format int
+
+const (
+	JSON Format = iota
+	PEM
+	JWS
+	JWS_JSON
+	COSE
+	PASETO
+)
+
+var Formats = map[Format][]string{
+	JSON:     {"json"},
+	PEM:      {"pem"},
+	JWS:      {"jws"},
+	JWS_JSON: {"jws-json"},
+	COSE:     {"cose"},
+	PASETO:   {"paseto"},
+}
+
+var FormatDescription = map[Format]string{
+	JSON:     "signed licence as an indented JSON document.",
+	PEM:      "armored text with a LICENCE block and a SIGNATURE block, safe for email and copy-paste.",
+	JWS:      "JWS compact serialization (a signed JWT) carrying the licence as claims.",
+	JWS_JSON: "JWS flattened JSON serialization carrying the licence as claims.",
+	COSE:     "compact binary COSE_Sign1 message wrapping the licence as a CBOR map.",
+	PASETO:   "PASETO v4.public token bound to the product name. Requires an Ed25519 key.",
+}
+
+type SignOptions struct {
+	// OmitKeyId leaves the signing key fingerprint out of the encoded licence.
+	OmitKeyId bool
+	// TSA is the URL of an RFC 3161 time-stamping authority used to
+	// timestamp the signature. Only the json and pem formats can carry it.
+	TSA string
+}
+
+type VerifyOptions struct {
+	// Product is the implicit assertion expected by formats that bind the
+	// licence to a product. It is required to verify those formats.
+	Product string
+	// Timestamp controls validation of embedded signature timestamps.
+	Timestamp timestamp.VerifyOptions
+}
+
+func SignLicenceAs(signer crypto.Signer, licence Licence, format Format, options SignOptions) ([]byte, error) {
+	if options.TSA != "" && format != JSON && format != PEM {
+		return nil, errors.New("timestamps are only supported by the json and pem formats")
+	}
+	switch format {
+	case JSON:
+		signed, err := SignLicence(signer, licence)
+		if err != nil {
+			return nil, err
+		}
+		if options.TSA != "" {
+			signature, err := base64.StdEncoding.DecodeString(signed.Signature)
+			if err != nil {
+				return nil, err
+			}
+			token, err := timestampSignature(options.TSA, signature)
+			if err != nil {
+				return nil, err
+			}
+			signed.Timestamp = base64.StdEncoding.EncodeToString(token)
+		}
+		return json.MarshalIndent(signed, "", "  ")
+	case PEM:
+		return signLicencePEM(signer, licence, options)
+	case JWS:
+		return signLicenceJWS(signer, licence, options)
+	case JWS_JSON:
+		return signLicenceJWSJSON(signer, licence, options)
+	case COSE:
+		return signLicenceCOSE(signer, licence, options)
+	case PASETO:
+		return signLicencePASETO(signer, licence, options)
+	default:
+		return nil, errors.New("invalid licence format")
+	}
+}
+
+func DetectFormat(data []byte) (Format, error) {
+	// A tagged COSE_Sign1 starts with tag 18 and an untagged one with a four element array.
+	if len(data) > 0 && (data[0] == 0xd2 || data[0] == 0x84) {
+		return COSE, nil
+	}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte(pasetoHeader)):
+		return PASETO, nil
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var fields map[string]json.RawMessage
+		err := json.Unmarshal(trimmed, &fields)
+		if err != nil {
+			return 0, err
+		}
+		if _, ok := fields["payload"]; ok {
+			return JWS_JSON, nil
+		}
+		return JSON, nil
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN ")):
+		return PEM, nil
+	case bytes.Count(trimmed, []byte(".")) == 2:
+		return JWS, nil
+	default:
+		return 0, errors.New("unrecognised licence format")
+	}
+}
+
+func VerifyLicence(data []byte, key crypto.PublicKey, options VerifyOptions) (Licence, error) {
+	format, err := DetectFormat(data)
+	if err != nil {
+		return Licence{}, err
+	}
+	var licence Licence
+	switch format {
+	case JSON:
+		return verifyLicenceJSON(data, key, options)
+	case PEM:
+		return verifyLicencePEM(data, key, options)
+	case JWS:
+		licence, err = verifyLicenceJWS(data, key)
+	case JWS_JSON:
+		licence, err = verifyLicenceJWSJSON(data, key)
+	case COSE:
+		licence, err = verifyLicenceCOSE(data, key)
+	case PASETO:
+		licence, err = verifyLicencePASETO(data, key, options)
+	default:
+		return Licence{}, errors.New("invalid licence format")
+	}
+	if err != nil {
+		return Licence{}, err
+	}
+	return licence, checkTimestamp(licence, nil, nil, options)
+}
+
+func parseJSON(data []byte) (SignedLicence, error) {
+	var signed SignedLicence
+	err := json.Unmarshal(data, &signed)
+	return signed, err
+}
+
+func (l SignedLicence) claims() (Licence, error) {
+	return l.Licence, nil
+}
+
+func (l SignedLicence) signingKeyId() (string, error) {
+	return "", nil
+}
+
+func (l SignedLicence) primarySignature() ([]byte, error) {
+	if l.Signature == "" {
+		return nil, ErrUnsigned
+	}
+	return base64.StdEncoding.DecodeString(l.Signature)
+}
+
+func verifyLicenceJSON(data []byte, key crypto.PublicKey, options VerifyOptions) (Licence, error) {
+	signed, err := parseJSON(data)
+	if err != nil {
+		return Licence{}, err
+	}
+	err = VerifyLicenceSignature(signed, key)
+	if err != nil {
+		return Licence{}, err
+	}
+	var signature, token []byte
+	if signed.Timestamp != "" {
+		signature, err = base64.StdEncoding.DecodeString(signed.Signature)
+		if err != nil {
+			return Licence{}, err
+		}
+		token, err = base64.StdEncoding.DecodeString(signed.Timestamp)
+		if err != nil {
+			return Licence{}, fmt.Errorf("invalid timestamp encoding: %w", err)
+		}
+	}
+	return signed.Licence, checkTimestamp(signed.Licence, token, signature, options)
+}
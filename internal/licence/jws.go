@@ -0,0 +1,316 @@
+package licence
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/sign"
+)
+
+type jwsHeader struct {
+	Algorithm string `json:"alg"`
+	KeyId     string `json:"kid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+}
+
+type jwsClaims struct {
+	Id          string   `json:"jti"`
+	Issuer      string   `json:"iss"`
+	IssuedAt    int64    `json:"iat"`
+	ExpiresAt   int64    `json:"exp"`
+	Name        string   `json:"name"`
+	Email       string   `json:"email"`
+	Product     string   `json:"product"`
+	Version     string   `json:"version"`
+	Tier        string   `json:"tier,omitempty"`
+	Features    []string `json:"features,omitempty"`
+	Predecessor string   `json:"predecessor,omitempty"`
+}
+
+type jwsSignature struct {
+	Protected string `json:"protected"`
+	Signature string `json:"signature"`
+}
+
+type jwsJSON struct {
+	Payload    string         `json:"payload"`
+	Protected  string         `json:"protected,omitempty"`
+	Signature  string         `json:"signature,omitempty"`
+	Signatures []jwsSignature `json:"signatures,omitempty"`
+}
+
+var jwsEncoding = base64.RawURLEncoding
+
+func jwsAlgorithm(publicKey crypto.PublicKey) (string, crypto.Hash, error) {
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		return "RS256", crypto.SHA256, nil
+	case *ecdsa.PublicKey:
+		switch publicKey.Curve {
+		case elliptic.P256():
+			return "ES256", crypto.SHA256, nil
+		case elliptic.P384():
+			return "ES384", crypto.SHA384, nil
+		case elliptic.P521():
+			return "ES512", crypto.SHA512, nil
+		}
+		return "", 0, errors.New("unsupported elliptic curve")
+	case ed25519.PublicKey:
+		return "EdDSA", 0, nil
+	default:
+		return "", 0, sign.ErrInvalidPublicKey
+	}
+}
+
+func parseDate(date string) (time.Time, error) {
+	return time.ParseInLocation(time.DateOnly, date, time.UTC)
+}
+
+// The expiry date is the last day a licence is valid, so exp is the start
+// of the following day, which is when JWT, CWT and PASETO consumers must
+// stop accepting it.
+func licenceToClaims(licence Licence) (jwsClaims, error) {
+	issued, err := parseDate(licence.IssueDate)
+	if err != nil {
+		return jwsClaims{}, fmt.Errorf("invalid licence.issue_date: %w", err)
+	}
+	expiry, err := parseDate(licence.ExpiryDate)
+	if err != nil {
+		return jwsClaims{}, fmt.Errorf("invalid licence.expiry_date: %w", err)
+	}
+	return jwsClaims{
+		Id:          licence.LicenceKey,
+		Issuer:      licence.Issuer,
+		IssuedAt:    issued.Unix(),
+		ExpiresAt:   expiry.AddDate(0, 0, 1).Unix(),
+		Name:        licence.Name,
+		Email:       licence.Email,
+		Product:     licence.Product,
+		Version:     licence.Version,
+		Tier:        licence.Tier,
+		Features:    licence.Features,
+		Predecessor: licence.Predecessor,
+	}, nil
+}
+
+func claimsToLicence(claims jwsClaims) Licence {
+	return Licence{
+		LicenceKey:  claims.Id,
+		Issuer:      claims.Issuer,
+		IssueDate:   time.Unix(claims.IssuedAt, 0).UTC().Format(time.DateOnly),
+		ExpiryDate:  time.Unix(claims.ExpiresAt, 0).UTC().AddDate(0, 0, -1).Format(time.DateOnly),
+		Name:        claims.Name,
+		Email:       claims.Email,
+		Product:     claims.Product,
+		Version:     claims.Version,
+		Tier:        claims.Tier,
+		Features:    claims.Features,
+		Predecessor: claims.Predecessor,
+	}
+}
+
+func signJWS(signer crypto.Signer, licence Licence, options SignOptions) (protected, payload, signature string, err error) {
+	licence, _, err = prepareLicence(licence)
+	if err != nil {
+		return "", "", "", err
+	}
+	claims, err := licenceToClaims(licence)
+	if err != nil {
+		return "", "", "", err
+	}
+	algorithm, hash, err := jwsAlgorithm(signer.Public())
+	if err != nil {
+		return "", "", "", err
+	}
+	header := jwsHeader{Algorithm: algorithm, Type: "JWT"}
+	if !options.OmitKeyId {
+		header.KeyId, err = key.Fingerprint(signer.Public())
+		if err != nil {
+			return "", "", "", err
+		}
+	}
+
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return "", "", "", err
+	}
+	claimsData, err := json.Marshal(claims)
+	if err != nil {
+		return "", "", "", err
+	}
+	protected = jwsEncoding.EncodeToString(headerData)
+	payload = jwsEncoding.EncodeToString(claimsData)
+
+	rawSignature, err := sign.SignMessageHash(signer, []byte(protected+"."+payload), hash)
+	if err != nil {
+		return "", "", "", err
+	}
+	if publicKey, ok := signer.Public().(*ecdsa.PublicKey); ok {
+		rawSignature, err = sign.ECDSAToRaw(rawSignature, publicKey)
+		if err != nil {
+			return "", "", "", err
+		}
+	}
+	return protected, payload, jwsEncoding.EncodeToString(rawSignature), nil
+}
+
+func signLicenceJWS(signer crypto.Signer, licence Licence, options SignOptions) ([]byte, error) {
+	protected, payload, signature, err := signJWS(signer, licence, options)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(protected + "." + payload + "." + signature), nil
+}
+
+func signLicenceJWSJSON(signer crypto.Signer, licence Licence, options SignOptions) ([]byte, error) {
+	protected, payload, signature, err := signJWS(signer, licence, options)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(jwsJSON{Payload: payload, Protected: protected, Signature: signature}, "", "  ")
+}
+
+// jwsLicence is a compact or JSON serialised JWS licence. Every signature
+// covers the same payload.
+type jwsLicence struct {
+	payload    string
+	signatures []jwsSignature
+}
+
+func parseJWS(data []byte) (jwsLicence, error) {
+	parts := strings.Split(string(bytes.TrimSpace(data)), ".")
+	if len(parts) != 3 {
+		return jwsLicence{}, errors.New("compact JWS must have exactly three parts")
+	}
+	return jwsLicence{payload: parts[1], signatures: []jwsSignature{{Protected: parts[0], Signature: parts[2]}}}, nil
+}
+
+func parseJWSJSON(data []byte) (jwsLicence, error) {
+	var envelope jwsJSON
+	err := json.Unmarshal(data, &envelope)
+	if err != nil {
+		return jwsLicence{}, err
+	}
+	signatures := envelope.Signatures
+	if envelope.Signature != "" {
+		signatures = []jwsSignature{{Protected: envelope.Protected, Signature: envelope.Signature}}
+	}
+	if len(signatures) == 0 {
+		return jwsLicence{}, errors.New("JWS contains no signatures")
+	}
+	return jwsLicence{payload: envelope.Payload, signatures: signatures}, nil
+}
+
+func parseJWSHeader(protected string) (jwsHeader, error) {
+	headerData, err := jwsEncoding.DecodeString(protected)
+	if err != nil {
+		return jwsHeader{}, fmt.Errorf("invalid JWS header encoding: %w", err)
+	}
+	var header jwsHeader
+	err = json.Unmarshal(headerData, &header)
+	if err != nil {
+		return jwsHeader{}, fmt.Errorf("invalid JWS header: %w", err)
+	}
+	return header, nil
+}
+
+func (j jwsLicence) claims() (Licence, error) {
+	claimsData, err := jwsEncoding.DecodeString(j.payload)
+	if err != nil {
+		return Licence{}, fmt.Errorf("invalid JWS payload encoding: %w", err)
+	}
+	var claims jwsClaims
+	err = json.Unmarshal(claimsData, &claims)
+	if err != nil {
+		return Licence{}, fmt.Errorf("invalid JWS payload: %w", err)
+	}
+	return claimsToLicence(claims), nil
+}
+
+func (j jwsLicence) signingKeyId() (string, error) {
+	header, err := parseJWSHeader(j.signatures[0].Protected)
+	return header.KeyId, err
+}
+
+func (j jwsLicence) primarySignature() ([]byte, error) {
+	return jwsEncoding.DecodeString(j.signatures[0].Signature)
+}
+
+// verifyJWSSignature checks one signature over payload.
+func verifyJWSSignature(s jwsSignature, payload string, publicKey crypto.PublicKey) error {
+	header, err := parseJWSHeader(s.Protected)
+	if err != nil {
+		return err
+	}
+	algorithm, hash, err := jwsAlgorithm(publicKey)
+	if err != nil {
+		return err
+	}
+	if header.Algorithm != algorithm {
+		return fmt.Errorf("%w: licence was signed using '%s' but public key uses '%s'", ErrKeyMismatch, header.Algorithm, algorithm)
+	}
+	if header.KeyId != "" {
+		keyId, err := key.Fingerprint(publicKey)
+		if err != nil {
+			return err
+		}
+		if header.KeyId != keyId {
+			return fmt.Errorf("%w: licence was signed by key '%s' but public key is '%s'", ErrKeyMismatch, header.KeyId, keyId)
+		}
+	}
+
+	rawSignature, err := jwsEncoding.DecodeString(s.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid JWS signature encoding: %w", err)
+	}
+	if publicKey, ok := publicKey.(*ecdsa.PublicKey); ok {
+		rawSignature, err = sign.ECDSAFromRaw(rawSignature, publicKey)
+		if err != nil {
+			return err
+		}
+	}
+	return sign.VerifySignatureHash(rawSignature, []byte(s.Protected+"."+payload), publicKey, hash)
+}
+
+// verifyJWS accepts the licence when any of its signatures verifies.
+func verifyJWS(j jwsLicence, publicKey crypto.PublicKey) (Licence, error) {
+	var errs []error
+	for _, s := range j.signatures {
+		err := verifyJWSSignature(s, j.payload, publicKey)
+		if err == nil {
+			return j.claims()
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 1 {
+		return Licence{}, errs[0]
+	}
+	return Licence{}, errors.Join(errs...)
+}
+
+func verifyLicenceJWS(data []byte, publicKey crypto.PublicKey) (Licence, error) {
+	j, err := parseJWS(data)
+	if err != nil {
+		return Licence{}, err
+	}
+	return verifyJWS(j, publicKey)
+}
+
+func verifyLicenceJWSJSON(data []byte, publicKey crypto.PublicKey) (Licence, error) {
+	j, err := parseJWSJSON(data)
+	if err != nil {
+		return Licence{}, err
+	}
+	return verifyJWS(j, publicKey)
+}
@@ -0,0 +1,45 @@
+package licence
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+)
+
+// TestExpiryClaim checks that exp is the end of the last valid day, so the
+// licence stays valid for standard token consumers through its expiry date.
+func TestExpiryClaim(t *testing.T) {
+	claims, err := licenceToClaims(testLicence())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC).Unix(); claims.ExpiresAt != want {
+		t.Fatalf("exp = %s, want %s", time.Unix(claims.ExpiresAt, 0).UTC(), time.Unix(want, 0).UTC())
+	}
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastDay := time.Date(2025, 1, 1, 23, 59, 59, 0, time.UTC)
+	for _, format := range []Format{JWS, JWS_JSON, COSE, PASETO} {
+		signed, err := SignLicenceAs(private, testLicence(), format, SignOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, _, err := Decode(signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.ExpiryDate != testLicence().ExpiryDate {
+			t.Errorf("%s: expiry_date = %s, want %s", Formats[format][0], decoded.ExpiryDate, testLicence().ExpiryDate)
+		}
+		if _, err = CheckValidity(decoded, lastDay); err != nil {
+			t.Errorf("%s: licence is not valid on its last day: %v", Formats[format][0], err)
+		}
+		if _, err = CheckValidity(decoded, lastDay.Add(time.Second)); err == nil {
+			t.Errorf("%s: licence is valid after its expiry date", Formats[format][0])
+		}
+	}
+}
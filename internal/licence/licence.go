@@ -5,36 +5,44 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/eslam-allam/file-signer/internal/constant"
 	"github.com/eslam-allam/file-signer/internal/sign"
+	"github.com/eslam-allam/file-signer/internal/timestamp"
 	"github.com/google/uuid"
 )
 
 const (
 	signatureBlock string = "SIGNATURE"
 	licenceBlock   string = "LICENCE"
+	timestampBlock string = "TIMESTAMP"
 )
 
 type schemaProperty struct {
-	Type        string `json:"type"`
-	Description string `json:"description"`
-	Pattern     string `json:"pattern,omitempty"`
-	Format      string `json:"format,omitempty"`
-	MinLength   int    `json:"minLength,omitempty"`
+	Type        string          `json:"type"`
+	Description string          `json:"description"`
+	Pattern     string          `json:"pattern,omitempty"`
+	Format      string          `json:"format,omitempty"`
+	MinLength   int             `json:"minLength,omitempty"`
+	Items       *schemaProperty `json:"items,omitempty"`
 }
 
 type licenceSchemaProperties struct {
-	LicenceKey schemaProperty `json:"licence_key"`
-	Name       schemaProperty `json:"name"`
-	Email      schemaProperty `json:"email"`
-	Product    schemaProperty `json:"product"`
-	Version    schemaProperty `json:"version"`
-	Issuer     schemaProperty `json:"issuer"`
-	IssueDate  schemaProperty `json:"issue_date"`
-	ExpiryDate schemaProperty `json:"expiry_date"`
+	LicenceKey  schemaProperty `json:"licence_key"`
+	Name        schemaProperty `json:"name"`
+	Email       schemaProperty `json:"email"`
+	Product     schemaProperty `json:"product"`
+	Version     schemaProperty `json:"version"`
+	Issuer      schemaProperty `json:"issuer"`
+	IssueDate   schemaProperty `json:"issue_date"`
+	ExpiryDate  schemaProperty `json:"expiry_date"`
+	Tier        schemaProperty `json:"tier"`
+	Features    schemaProperty `json:"features"`
+	Predecessor schemaProperty `json:"predecessor"`
 }
 
 type licenceSchemaDefinition struct {
@@ -90,29 +98,124 @@ var licenceSchema licenceSchemaDefinition = licenceSchemaDefinition{
 			Description: "Date of licence expiry in this format (yyyy-mm-dd).",
 			Format:      "date",
 		},
+		Tier: schemaProperty{
+			Type:        "string",
+			Description: "Tier of the licence. An issuance policy can require features per tier",
+		},
+		Features: schemaProperty{
+			Type:        "array",
+			Description: "Features enabled by this licence, each listed once",
+			Items: &schemaProperty{
+				Type:        "string",
+				Description: "Feature name, optionally with a value as name=value, e.g. export or seats=5",
+				Pattern:     featurePattern,
+				MinLength:   1,
+			},
+		},
+		Predecessor: schemaProperty{
+			Type:        "string",
+			Description: "SHA-256 of the signature of the licence this one renews or amends. Filled by licence renew and licence edit.",
+			Pattern:     "^[0-9a-f]{64}$",
+		},
 	},
 	Required: []string{"name", "email", "product", "version", "issuer", "expiry_date"},
 }
 
 type Licence struct {
-	Schema     string `json:"$schema"`
-	LicenceKey string `json:"licence_key"`
-	Name       string `json:"name"`
-	Email      string `json:"email"`
-	Product    string `json:"product"`
-	Version    string `json:"version"`
-	Issuer     string `json:"issuer"`
-	IssueDate  string `json:"issue_date"`
-	ExpiryDate string `json:"expiry_date"`
+	Schema      string   `json:"$schema"`
+	LicenceKey  string   `json:"licence_key"`
+	Name        string   `json:"name"`
+	Email       string   `json:"email"`
+	Product     string   `json:"product"`
+	Version     string   `json:"version"`
+	Issuer      string   `json:"issuer"`
+	IssueDate   string   `json:"issue_date"`
+	ExpiryDate  string   `json:"expiry_date"`
+	Tier        string   `json:"tier,omitempty"`
+	Features    []string `json:"features,omitempty"`
+	Predecessor string   `json:"predecessor,omitempty"`
 }
 
 type SignedLicence struct {
 	Licence
-	Signature string `json:"signature"`
+	Signature    string        `json:"signature"`
+	Timestamp    string        `json:"timestamp,omitempty"`
+	Cosignatures []Cosignature `json:"cosignatures,omitempty"`
+}
+
+var fieldSetters = map[string]func(*Licence, string){
+	"licence_key": func(l *Licence, v string) { l.LicenceKey = v },
+	"name":        func(l *Licence, v string) { l.Name = v },
+	"email":       func(l *Licence, v string) { l.Email = v },
+	"product":     func(l *Licence, v string) { l.Product = v },
+	"version":     func(l *Licence, v string) { l.Version = v },
+	"issuer":      func(l *Licence, v string) { l.Issuer = v },
+	"issue_date":  func(l *Licence, v string) { l.IssueDate = v },
+	"expiry_date": func(l *Licence, v string) { l.ExpiryDate = v },
+	"tier":        func(l *Licence, v string) { l.Tier = v },
+	"features":    func(l *Licence, v string) { l.Features = splitFeatures(v) },
+}
+
+// Fields lists the licence fields that can be set by name.
+var Fields = []string{"licence_key", "name", "email", "product", "version", "issuer", "issue_date", "expiry_date", "tier", "features"}
+
+func splitFeatures(value string) []string {
+	var features []string
+	for _, feature := range strings.Split(value, ",") {
+		if feature = strings.TrimSpace(feature); feature != "" {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+// SetField sets the licence field with the given JSON name. Features are
+// given as a comma separated list.
+func SetField(l *Licence, field, value string) error {
+	set, ok := fieldSetters[field]
+	if !ok {
+		return fmt.Errorf("unknown licence field '%s'", field)
+	}
+	set(l, value)
+	return nil
+}
+
+// GetField returns the licence field with the given JSON name in the form
+// accepted by SetField.
+func GetField(l Licence, field string) string {
+	switch field {
+	case "licence_key":
+		return l.LicenceKey
+	case "name":
+		return l.Name
+	case "email":
+		return l.Email
+	case "product":
+		return l.Product
+	case "version":
+		return l.Version
+	case "issuer":
+		return l.Issuer
+	case "issue_date":
+		return l.IssueDate
+	case "expiry_date":
+		return l.ExpiryDate
+	case "tier":
+		return l.Tier
+	case "features":
+		return strings.Join(l.Features, ",")
+	}
+	return ""
+}
+
+// New returns an empty licence referring to the schema written alongside
+// licences by GetTemplate.
+func New() Licence {
+	return Licence{Schema: filepath.Join(".", constant.SCHEMA_FILE_NAME)}
 }
 
 func GetTemplate() (licence []byte, schema []byte, err error) {
-	licence, err = json.MarshalIndent(Licence{Schema: filepath.Join(".", constant.SCHEMA_FILE_NAME)}, "", "  ")
+	licence, err = json.MarshalIndent(New(), "", "  ")
 	if err != nil {
 		return nil, nil, err
 	}
@@ -139,13 +242,19 @@ func validateLicence(licence Licence) error {
 	if licence.ExpiryDate == "" {
 		return errors.New("licence.expiry_date cannot be empty")
 	}
+	err := validateFeatures(licence.Features)
+	if err != nil {
+		return fmt.Errorf("invalid licence.features: %w", err)
+	}
 	return nil
 }
 
-func SignLicence(key crypto.PrivateKey, licence Licence) (SignedLicence, error) {
+// Prepare validates licence and fills in the licence key and issue date
+// when they are missing, exactly as signing would.
+func Prepare(licence Licence) (Licence, error) {
 	err := validateLicence(licence)
 	if err != nil {
-		return SignedLicence{}, err
+		return Licence{}, err
 	}
 
 	if licence.LicenceKey == "" {
@@ -155,12 +264,28 @@ func SignLicence(key crypto.PrivateKey, licence Licence) (SignedLicence, error)
 	if licence.IssueDate == "" {
 		licence.IssueDate = time.Now().Format(time.DateOnly)
 	}
+	return licence, nil
+}
+
+func prepareLicence(licence Licence) (Licence, []byte, error) {
+	licence, err := Prepare(licence)
+	if err != nil {
+		return Licence{}, nil, err
+	}
 
 	licenceData, err := json.Marshal(licence)
+	if err != nil {
+		return Licence{}, nil, err
+	}
+	return licence, licenceData, nil
+}
+
+func SignLicence(signer crypto.Signer, licence Licence) (SignedLicence, error) {
+	licence, licenceData, err := prepareLicence(licence)
 	if err != nil {
 		return SignedLicence{}, err
 	}
-	signature, err := sign.SignMessage(key, licenceData)
+	signature, err := sign.SignMessage(signer, licenceData)
 	if err != nil {
 		return SignedLicence{}, err
 	}
@@ -179,3 +304,28 @@ func VerifyLicenceSignature(l SignedLicence, key crypto.PublicKey) error {
 	}
 	return sign.VerifySignature(decodedSignature, licenceData, key)
 }
+
+// timestampSignature obtains an RFC 3161 token over signature from the TSA at url.
+func timestampSignature(url string, signature []byte) ([]byte, error) {
+	if url == "" {
+		return nil, nil
+	}
+	return timestamp.Request(url, signature)
+}
+
+// checkTimestamp validates an optional timestamp token over signature and
+// uses its trusted time to reject licences signed after they expired.
+func checkTimestamp(licence Licence, token, signature []byte, options VerifyOptions) error {
+	signedAt, err := timestamp.Check(token, signature, options.Timestamp)
+	if err != nil || signedAt.IsZero() {
+		return err
+	}
+	expiry, err := parseDate(licence.ExpiryDate)
+	if err != nil {
+		return fmt.Errorf("invalid licence.expiry_date: %w", err)
+	}
+	if !signedAt.Before(expiry.AddDate(0, 0, 1)) {
+		return fmt.Errorf("%w before it was timestamped at %s", ErrExpired, signedAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package licence
+
+import (
+	"crypto"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/sign"
+)
+
+const (
+	algorithmHeader string = "Algorithm"
+	keyIdHeader     string = "Key-Id"
+)
+
+func signLicencePEM(signer crypto.Signer, licence Licence, options SignOptions) ([]byte, error) {
+	_, licenceData, err := prepareLicence(licence)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := sign.SignMessage(signer, licenceData)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := sign.Algorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{algorithmHeader: algorithm}
+	if !options.OmitKeyId {
+		headers[keyIdHeader], err = key.Fingerprint(signer.Public())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	armored := pem.EncodeToMemory(&pem.Block{Type: licenceBlock, Bytes: licenceData})
+	armored = append(armored, pem.EncodeToMemory(&pem.Block{
+		Type:    signatureBlock,
+		Headers: headers,
+		Bytes:   signature,
+	})...)
+	token, err := timestampSignature(options.TSA, signature)
+	if err != nil {
+		return nil, err
+	}
+	if token != nil {
+		armored = append(armored, pem.EncodeToMemory(&pem.Block{Type: timestampBlock, Bytes: token})...)
+	}
+	return armored, nil
+}
+
+// pemLicence is a PEM licence split into its blocks.
+type pemLicence struct {
+	licenceData []byte
+	signature   []byte
+	headers     map[string]string
+	token       []byte
+}
+
+func parsePEM(data []byte) (pemLicence, error) {
+	var p pemLicence
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case licenceBlock:
+			p.licenceData = block.Bytes
+		case signatureBlock:
+			p.signature = block.Bytes
+			p.headers = block.Headers
+		case timestampBlock:
+			p.token = block.Bytes
+		}
+	}
+	if p.licenceData == nil {
+		return pemLicence{}, fmt.Errorf("block '%s' not found", licenceBlock)
+	}
+	return p, nil
+}
+
+func (p pemLicence) claims() (Licence, error) {
+	var licence Licence
+	err := json.Unmarshal(p.licenceData, &licence)
+	return licence, err
+}
+
+func (p pemLicence) signingKeyId() (string, error) {
+	return p.headers[keyIdHeader], nil
+}
+
+func (p pemLicence) primarySignature() ([]byte, error) {
+	if p.signature == nil {
+		return nil, fmt.Errorf("block '%s' not found", signatureBlock)
+	}
+	return p.signature, nil
+}
+
+func verifyLicencePEM(data []byte, publicKey crypto.PublicKey, options VerifyOptions) (Licence, error) {
+	p, err := parsePEM(data)
+	if err != nil {
+		return Licence{}, err
+	}
+	signature, err := p.primarySignature()
+	if err != nil {
+		return Licence{}, err
+	}
+
+	algorithm, err := sign.Algorithm(publicKey)
+	if err != nil {
+		return Licence{}, err
+	}
+	if p.headers[algorithmHeader] != algorithm {
+		return Licence{}, fmt.Errorf("%w: licence was signed using '%s' but public key uses '%s'", ErrKeyMismatch, p.headers[algorithmHeader], algorithm)
+	}
+	if signedKeyId, ok := p.headers[keyIdHeader]; ok {
+		keyId, err := key.Fingerprint(publicKey)
+		if err != nil {
+			return Licence{}, err
+		}
+		if signedKeyId != keyId {
+			return Licence{}, fmt.Errorf("%w: licence was signed by key '%s' but public key is '%s'", ErrKeyMismatch, signedKeyId, keyId)
+		}
+	}
+
+	err = sign.VerifySignature(signature, p.licenceData, publicKey)
+	if err != nil {
+		return Licence{}, err
+	}
+	licence, err := p.claims()
+	if err != nil {
+		return Licence{}, err
+	}
+	return licence, checkTimestamp(licence, p.token, signature, options)
+}
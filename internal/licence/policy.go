@@ -0,0 +1,216 @@
+package licence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"golang.org/x/exp/maps"
+)
+
+// IssuancePolicy restricts what may be signed. Empty sections allow anything.
+//
+//	{
+//	  "issuers": ["sales@example.com"],
+//	  "products": {
+//	    "editor": {
+//	      "max_duration": "1y",
+//	      "tiers": {"trial": [], "pro": ["sso", "audit-log"]}
+//	    }
+//	  },
+//	  "profiles": {
+//	    "trial": {"duration": "30d", "defaults": {"tier": "trial", "version": "1"}}
+//	  }
+//	}
+type IssuancePolicy struct {
+	Issuers  []string                 `json:"issuers"`
+	Products map[string]ProductPolicy `json:"products"`
+	Profiles map[string]Profile       `json:"profiles"`
+}
+
+type ProductPolicy struct {
+	// MaxDuration bounds the time from issue date to expiry date, e.g. 1y.
+	MaxDuration string `json:"max_duration"`
+	// Tiers lists the allowed tiers and the features each one requires. A
+	// bare feature name is met whatever its value, name=value only by that
+	// value.
+	Tiers map[string][]string `json:"tiers"`
+}
+
+// Profile supplies defaults for licences signed with it.
+type Profile struct {
+	// Duration sets the expiry date relative to the issue date when empty.
+	Duration string `json:"duration"`
+	// Defaults fills empty licence fields by name.
+	Defaults map[string]string `json:"defaults"`
+	// Features are added to the licence features unless the licence already
+	// lists a feature of the same name.
+	Features []string `json:"features"`
+}
+
+func LoadIssuancePolicy(path string) (IssuancePolicy, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return IssuancePolicy{}, err
+	}
+	var policy IssuancePolicy
+	err = json.Unmarshal(data, &policy)
+	if err != nil {
+		return IssuancePolicy{}, fmt.Errorf("invalid issuance policy '%s': %w", path, err)
+	}
+	for product, p := range policy.Products {
+		if p.MaxDuration != "" {
+			_, err = addPeriod(time.Time{}, p.MaxDuration)
+			if err != nil {
+				return IssuancePolicy{}, fmt.Errorf("invalid max_duration for product '%s': %w", product, err)
+			}
+		}
+		for tier, required := range p.Tiers {
+			err = validateFeatures(required)
+			if err != nil {
+				return IssuancePolicy{}, fmt.Errorf("invalid features for tier '%s' of product '%s': %w", tier, product, err)
+			}
+		}
+	}
+	for name, profile := range policy.Profiles {
+		if profile.Duration != "" {
+			_, err = addPeriod(time.Time{}, profile.Duration)
+			if err != nil {
+				return IssuancePolicy{}, fmt.Errorf("invalid duration for profile '%s': %w", name, err)
+			}
+		}
+		for field := range profile.Defaults {
+			if !slices.Contains(Fields, field) || field == "licence_key" {
+				return IssuancePolicy{}, fmt.Errorf("profile '%s' sets unknown or fixed field '%s'", name, field)
+			}
+		}
+		err = validateFeatures(profile.Features)
+		if err != nil {
+			return IssuancePolicy{}, fmt.Errorf("invalid features for profile '%s': %w", name, err)
+		}
+	}
+	return policy, nil
+}
+
+// ApplyProfile fills the empty fields of licence from the named profile.
+func (p IssuancePolicy) ApplyProfile(licence Licence, name string) (Licence, error) {
+	profile, ok := p.Profiles[name]
+	if !ok {
+		return Licence{}, fmt.Errorf("issuance policy has no profile '%s'", name)
+	}
+	var defaults Licence
+	for field, value := range profile.Defaults {
+		SetField(&defaults, field, value)
+	}
+	licence = fillEmpty(licence, defaults)
+	for _, feature := range profile.Features {
+		licence = addFeature(licence, feature)
+	}
+	if licence.ExpiryDate == "" && profile.Duration != "" {
+		issued := time.Now().UTC()
+		if licence.IssueDate != "" {
+			var err error
+			issued, err = parseDate(licence.IssueDate)
+			if err != nil {
+				return Licence{}, fmt.Errorf("invalid licence.issue_date: %w", err)
+			}
+		}
+		expiry, err := addPeriod(issued, profile.Duration)
+		if err != nil {
+			return Licence{}, err
+		}
+		licence.ExpiryDate = expiry.Format(time.DateOnly)
+	}
+	return licence, nil
+}
+
+// Check reports every way a prepared licence violates the policy.
+func (p IssuancePolicy) Check(licence Licence) error {
+	return p.check(licence, licence.IssueDate)
+}
+
+// CheckRenewal is Check for a licence replacing previous. The max_duration
+// of its product is measured from the later of its issue date and the
+// expiry date of previous, so a licence renewed early may run one full term
+// past its current expiry.
+func (p IssuancePolicy) CheckRenewal(previous, licence Licence) error {
+	from := licence.IssueDate
+	if _, err := parseDate(previous.ExpiryDate); err == nil && previous.ExpiryDate > from {
+		from = previous.ExpiryDate
+	}
+	return p.check(licence, from)
+}
+
+// check enforces the policy on licence, measuring max_duration from the
+// yyyy-mm-dd date from.
+func (p IssuancePolicy) check(licence Licence, from string) error {
+	var violations []error
+	if len(p.Issuers) != 0 && !slices.Contains(p.Issuers, licence.Issuer) {
+		violations = append(violations, fmt.Errorf("issuer '%s' is not allowed", licence.Issuer))
+	}
+	if len(p.Products) == 0 {
+		return errors.Join(violations...)
+	}
+	product, ok := p.Products[licence.Product]
+	if !ok {
+		violations = append(violations, fmt.Errorf("product '%s' is not allowed, expected one of: %s",
+			licence.Product, strings.Join(sorted(maps.Keys(p.Products)), ", ")))
+		return errors.Join(violations...)
+	}
+
+	if product.MaxDuration != "" {
+		start, startErr := parseDate(from)
+		expiry, expiryErr := parseDate(licence.ExpiryDate)
+		if startErr != nil || expiryErr != nil {
+			violations = append(violations, errors.New("issue_date and expiry_date must be yyyy-mm-dd dates"))
+		} else if limit, _ := addPeriod(start, product.MaxDuration); expiry.After(limit) {
+			violations = append(violations, fmt.Errorf("licence runs until %s but product '%s' allows at most %s (until %s)",
+				licence.ExpiryDate, licence.Product, product.MaxDuration, limit.Format(time.DateOnly)))
+		}
+	}
+
+	if len(product.Tiers) != 0 {
+		required, ok := product.Tiers[licence.Tier]
+		if !ok {
+			violations = append(violations, fmt.Errorf("tier '%s' is not allowed for product '%s', expected one of: %s",
+				licence.Tier, licence.Product, strings.Join(sorted(maps.Keys(product.Tiers)), ", ")))
+		}
+		for _, feature := range required {
+			if !licence.HasFeature(feature) {
+				violations = append(violations, fmt.Errorf("tier '%s' requires feature '%s'", licence.Tier, feature))
+			}
+		}
+	}
+	return errors.Join(violations...)
+}
+
+func fillEmpty(licence, defaults Licence) Licence {
+	fields := []struct{ value, fallback *string }{
+		{&licence.Name, &defaults.Name},
+		{&licence.Email, &defaults.Email},
+		{&licence.Product, &defaults.Product},
+		{&licence.Version, &defaults.Version},
+		{&licence.Issuer, &defaults.Issuer},
+		{&licence.IssueDate, &defaults.IssueDate},
+		{&licence.ExpiryDate, &defaults.ExpiryDate},
+		{&licence.Tier, &defaults.Tier},
+	}
+	for _, field := range fields {
+		if *field.value == "" {
+			*field.value = *field.fallback
+		}
+	}
+	if len(licence.Features) == 0 {
+		licence.Features = defaults.Features
+	}
+	return licence
+}
+
+func sorted(keys []string) []string {
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,46 @@
+package licence
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckRenewal(t *testing.T) {
+	policy := IssuancePolicy{Products: map[string]ProductPolicy{"editor": {MaxDuration: "1y"}}}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	previous := testLicence()
+	previous.IssueDate = today.AddDate(0, -6, 0).Format(time.DateOnly)
+	previous.ExpiryDate = today.AddDate(0, 6, 0).Format(time.DateOnly)
+	previous.Tier = ""
+
+	renewed, err := Extend(previous, "1y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = policy.Check(renewed); err == nil {
+		t.Fatal("Check measured an early renewal from its current expiry, want from its issue date")
+	}
+	if err = policy.CheckRenewal(previous, renewed); err != nil {
+		t.Fatalf("renewing six months early by one year: %v", err)
+	}
+
+	renewed, err = Extend(previous, "13m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = policy.CheckRenewal(previous, renewed)
+	if err == nil || !strings.Contains(err.Error(), "allows at most 1y") {
+		t.Fatalf("renewing by more than max_duration: %v", err)
+	}
+
+	// An expired licence is renewed from today.
+	previous.ExpiryDate = today.AddDate(0, -1, 0).Format(time.DateOnly)
+	renewed, err = Extend(previous, "1y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = policy.CheckRenewal(previous, renewed); err != nil {
+		t.Fatalf("renewing an expired licence by one year: %v", err)
+	}
+}
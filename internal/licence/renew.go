@@ -0,0 +1,105 @@
+package licence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var periodPattern = regexp.MustCompile(`^(\d+)([ymwd])$`)
+
+var ErrUnsigned = errors.New("licence is not signed")
+
+// signature extracts the raw primary signature from a signed licence.
+func signature(data []byte) ([]byte, error) {
+	parsed, err := parseLicence(data)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.primarySignature()
+}
+
+// SignatureFingerprint returns the hex SHA-256 of the signature of a signed
+// licence.
+func SignatureFingerprint(data []byte) (string, error) {
+	sig, err := signature(data)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(sig)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Predecessor returns the signature fingerprint of a signed licence, which
+// identifies it as the predecessor of a licence that replaces it.
+func Predecessor(data []byte) (string, error) {
+	return SignatureFingerprint(data)
+}
+
+// Successor returns a copy of previous that keeps its licence key and
+// records the signed licence it replaces.
+func Successor(previous Licence, signed []byte) (Licence, error) {
+	predecessor, err := Predecessor(signed)
+	if err != nil {
+		return Licence{}, err
+	}
+	previous.Predecessor = predecessor
+	return previous, nil
+}
+
+// addMonths adds n months to t, clamping the day to the end of a shorter
+// month where time.AddDate would overflow into the next, so that
+// 2024-01-31 + 1m is 2024-02-29 rather than 2024-03-02.
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	first = first.AddDate(0, n, 0)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+// addPeriod adds period (e.g. 1y, 6m, 2w, 30d) to t.
+func addPeriod(t time.Time, period string) (time.Time, error) {
+	match := periodPattern.FindStringSubmatch(period)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("invalid period '%s', expected a number followed by y, m, w or d", period)
+	}
+	n, err := strconv.Atoi(match[1])
+	if err != nil || n == 0 {
+		return time.Time{}, fmt.Errorf("invalid period '%s'", period)
+	}
+	switch match[2] {
+	case "y":
+		return addMonths(t, 12*n), nil
+	case "m":
+		return addMonths(t, n), nil
+	case "w":
+		return t.AddDate(0, 0, 7*n), nil
+	default:
+		return t.AddDate(0, 0, n), nil
+	}
+}
+
+// Extend moves the expiry date of licence forward by period (e.g. 1y, 6m,
+// 2w, 30d), counting from the current expiry date or from today if the
+// licence has already expired. The licence is reissued today.
+func Extend(licence Licence, period string) (Licence, error) {
+	expiry, err := parseDate(licence.ExpiryDate)
+	if err != nil {
+		return Licence{}, fmt.Errorf("invalid licence.expiry_date: %w", err)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if expiry.Before(today) {
+		expiry = today
+	}
+	expiry, err = addPeriod(expiry, period)
+	if err != nil {
+		return Licence{}, err
+	}
+	licence.ExpiryDate = expiry.Format(time.DateOnly)
+	licence.IssueDate = today.Format(time.DateOnly)
+	return licence, nil
+}
@@ -0,0 +1,100 @@
+package licence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddPeriod(t *testing.T) {
+	tests := []struct {
+		from   string
+		period string
+		want   string
+	}{
+		{"2024-03-15", "1y", "2025-03-15"},
+		{"2024-03-15", "6m", "2024-09-15"},
+		{"2024-03-15", "2w", "2024-03-29"},
+		{"2024-03-15", "30d", "2024-04-14"},
+		{"2024-01-31", "1m", "2024-02-29"},
+		{"2023-01-31", "1m", "2023-02-28"},
+		{"2024-03-31", "1m", "2024-04-30"},
+		{"2024-08-31", "6m", "2025-02-28"},
+		{"2024-01-30", "13m", "2025-02-28"},
+		{"2024-12-31", "2m", "2025-02-28"},
+		{"2024-02-29", "1y", "2025-02-28"},
+		{"2024-02-29", "4y", "2028-02-29"},
+		{"2024-01-31", "31d", "2024-03-02"},
+	}
+	for _, test := range tests {
+		from, err := parseDate(test.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := addPeriod(from, test.period)
+		if err != nil {
+			t.Fatalf("addPeriod(%s, %s): %v", test.from, test.period, err)
+		}
+		if got.Format(time.DateOnly) != test.want {
+			t.Errorf("addPeriod(%s, %s) = %s, want %s", test.from, test.period, got.Format(time.DateOnly), test.want)
+		}
+	}
+
+	for _, invalid := range []string{"", "0d", "00m", "1", "y", "1q", "-1y", "1.5y", " 1y", "1Y", "99999999999999999999d"} {
+		_, err := addPeriod(time.Now(), invalid)
+		if err == nil {
+			t.Errorf("addPeriod accepted period %q", invalid)
+		}
+	}
+}
+
+func TestExtend(t *testing.T) {
+	now := time.Now().UTC()
+	today := now.Format(time.DateOnly)
+	date := func(years, months, days int) string {
+		return now.AddDate(years, months, days).Format(time.DateOnly)
+	}
+
+	tests := []struct {
+		name   string
+		expiry string
+		period string
+		want   string
+	}{
+		{"active licence extends from its expiry", date(0, 0, 10), "30d", date(0, 0, 40)},
+		{"expired licence extends from today", "2020-01-31", "30d", date(0, 0, 30)},
+		{"licence expiring today", today, "1w", date(0, 0, 7)},
+		{"end of month", "2999-01-31", "1m", "2999-02-28"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := testLicence()
+			l.ExpiryDate = test.expiry
+			extended, err := Extend(l, test.period)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if extended.ExpiryDate != test.want {
+				t.Fatalf("expiry = %s, want %s", extended.ExpiryDate, test.want)
+			}
+			if extended.IssueDate != today {
+				t.Fatalf("issue date = %s, want today %s", extended.IssueDate, today)
+			}
+			if extended.LicenceKey != l.LicenceKey {
+				t.Fatal("Extend changed the licence key")
+			}
+		})
+	}
+
+	l := testLicence()
+	for _, period := range []string{"", "0y", "1x"} {
+		_, err := Extend(l, period)
+		if err == nil {
+			t.Errorf("Extend accepted period %q", period)
+		}
+	}
+	l.ExpiryDate = "31/01/2025"
+	_, err := Extend(l, "1y")
+	if err == nil {
+		t.Fatal("Extend accepted an invalid expiry date")
+	}
+}
@@ -0,0 +1,44 @@
+package licence
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrKeyMismatch is returned when a licence was signed by a different key
+	// or algorithm than the one it is verified with.
+	ErrKeyMismatch = errors.New("public key does not match the licence")
+	ErrExpired     = errors.New("licence expired")
+	ErrNotYetValid = errors.New("licence is not valid yet")
+)
+
+// CheckValidity checks that the licence is valid on the date of now and
+// returns the number of days left until its expiry date, which is the last
+// day the licence is valid.
+func CheckValidity(licence Licence, now time.Time) (int, error) {
+	expiry, err := parseDate(licence.ExpiryDate)
+	if err != nil {
+		return 0, fmt.Errorf("invalid licence.expiry_date: %w", err)
+	}
+	today, err := parseDate(now.Format(time.DateOnly))
+	if err != nil {
+		return 0, err
+	}
+	days := int(expiry.Sub(today).Hours() / 24)
+
+	if licence.IssueDate != "" {
+		issued, err := parseDate(licence.IssueDate)
+		if err != nil {
+			return 0, fmt.Errorf("invalid licence.issue_date: %w", err)
+		}
+		if today.Before(issued) {
+			return days, fmt.Errorf("%w: valid from %s", ErrNotYetValid, licence.IssueDate)
+		}
+	}
+	if days < 0 {
+		return days, fmt.Errorf("%w on %s", ErrExpired, licence.ExpiryDate)
+	}
+	return days, nil
+}
@@ -0,0 +1,90 @@
+// Package prompt asks questions on a terminal.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{in: bufio.NewReader(in), out: out}
+}
+
+// IsTerminal reports whether stdin is an interactive terminal.
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+func (p *Prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			return "", errors.New("input closed")
+		}
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Ask shows label and reads an answer, using fallback when the answer is
+// empty. validate may rewrite the answer; the question is repeated until it
+// accepts it.
+func (p *Prompter) Ask(label, fallback string, validate func(string) (string, error)) (string, error) {
+	for {
+		if fallback != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", label, fallback)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", label)
+		}
+		answer, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = fallback
+		}
+		if validate == nil {
+			return answer, nil
+		}
+		answer, err = validate(answer)
+		if err == nil {
+			return answer, nil
+		}
+		fmt.Fprintf(p.out, "  %v\n", err)
+	}
+}
+
+// Confirm asks a yes/no question.
+func (p *Prompter) Confirm(label string, fallback bool) (bool, error) {
+	options := "y/N"
+	if fallback {
+		options = "Y/n"
+	}
+	for {
+		fmt.Fprintf(p.out, "%s [%s]: ", label, options)
+		answer, err := p.readLine()
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return fallback, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.out, "  please answer y or n")
+	}
+}
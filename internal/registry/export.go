@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+type ExportFormat int
+
+const (
+	CSV ExportFormat = iota
+	JSON
+)
+
+var ExportFormats = map[ExportFormat][]string{
+	CSV:  {"csv"},
+	JSON: {"json"},
+}
+
+var ExportFormatDescription = map[ExportFormat]string{
+	CSV:  "comma separated values with a header row.",
+	JSON: "indented JSON array of records.",
+}
+
+var csvHeader = []string{
+	"id", "licence_key", "name", "email", "product", "version", "issuer",
+	"issue_date", "expiry_date", "key_id", "format", "file", "file_hash", "signed_at",
+}
+
+func Export(records []Record, format ExportFormat) ([]byte, error) {
+	switch format {
+	case CSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write(csvHeader)
+		for _, r := range records {
+			w.Write([]string{
+				strconv.FormatUint(r.Id, 10), r.LicenceKey, r.Name, r.Email, r.Product, r.Version, r.Issuer,
+				r.IssueDate, r.ExpiryDate, r.KeyId, r.Format, r.File, r.FileHash, r.SignedAt.Format(time.RFC3339),
+			})
+		}
+		w.Flush()
+		return buf.Bytes(), w.Error()
+	case JSON:
+		if records == nil {
+			records = []Record{}
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	default:
+		return nil, errors.New("invalid export format")
+	}
+}
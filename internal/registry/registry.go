@@ -0,0 +1,180 @@
+// Package registry records every signed licence in a local bbolt database
+// so issued licences can be listed, searched and exported later.
+package registry
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	DEFAULT_FILE_NAME string = "registry.db"
+	applicationDir    string = "file-signer"
+)
+
+var licencesBucket = []byte("licences")
+
+type Record struct {
+	Id         uint64    `json:"id"`
+	LicenceKey string    `json:"licence_key"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	Product    string    `json:"product"`
+	Version    string    `json:"version"`
+	Issuer     string    `json:"issuer"`
+	IssueDate  string    `json:"issue_date"`
+	ExpiryDate string    `json:"expiry_date"`
+	KeyId      string    `json:"key_id"`
+	Format     string    `json:"format"`
+	File       string    `json:"file"`
+	FileHash   string    `json:"file_hash"`
+	SignedAt   time.Time `json:"signed_at"`
+}
+
+// Query filters records. Empty fields match everything.
+type Query struct {
+	// Customer matches a substring of the name or email, ignoring case.
+	Customer string
+	// Product matches the product name, ignoring case.
+	Product string
+	// ExpiresAfter and ExpiresBefore bound the expiry date (yyyy-mm-dd), inclusive.
+	ExpiresAfter  string
+	ExpiresBefore string
+}
+
+type Registry struct {
+	db *bolt.DB
+}
+
+// DefaultPath is the registry location used when none is configured.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, applicationDir, DEFAULT_FILE_NAME), nil
+}
+
+func Open(path string) (*Registry, error) {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make intermediate directories for path '%s': %w", path, err)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open registry '%s': %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(licencesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Registry{db: db}, nil
+}
+
+func (r *Registry) Close() error {
+	return r.db.Close()
+}
+
+// Add stores record under a new id and returns it.
+func (r *Registry) Add(record Record) (Record, error) {
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(licencesBucket)
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		record.Id = id
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(binary.BigEndian.AppendUint64(nil, id), data)
+	})
+	return record, err
+}
+
+func (q Query) matches(record Record) bool {
+	if q.Customer != "" {
+		customer := strings.ToLower(q.Customer)
+		if !strings.Contains(strings.ToLower(record.Name), customer) &&
+			!strings.Contains(strings.ToLower(record.Email), customer) {
+			return false
+		}
+	}
+	if q.Product != "" && !strings.EqualFold(q.Product, record.Product) {
+		return false
+	}
+	// Dates are yyyy-mm-dd so they order lexically.
+	if q.ExpiresAfter != "" && record.ExpiryDate < q.ExpiresAfter {
+		return false
+	}
+	if q.ExpiresBefore != "" && record.ExpiryDate > q.ExpiresBefore {
+		return false
+	}
+	return true
+}
+
+func (q Query) validate() error {
+	for _, date := range []string{q.ExpiresAfter, q.ExpiresBefore} {
+		if date == "" {
+			continue
+		}
+		_, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return fmt.Errorf("invalid date '%s', expected yyyy-mm-dd", date)
+		}
+	}
+	return nil
+}
+
+// Search returns the records matching query in signing order.
+func (r *Registry) Search(query Query) ([]Record, error) {
+	err := query.validate()
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	err = r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(licencesBucket).ForEach(func(_, data []byte) error {
+			var record Record
+			err := json.Unmarshal(data, &record)
+			if err != nil {
+				return err
+			}
+			if query.matches(record) {
+				records = append(records, record)
+			}
+			return nil
+		})
+	})
+	return records, err
+}
+
+// Find returns every signing of the licence with the given key.
+func (r *Registry) Find(licenceKey string) ([]Record, error) {
+	records, err := r.Search(Query{})
+	if err != nil {
+		return nil, err
+	}
+	var found []Record
+	for _, record := range records {
+		if record.LicenceKey == licenceKey {
+			found = append(found, record)
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("licence '%s' is not in the registry", licenceKey)
+	}
+	return found, nil
+}
@@ -0,0 +1,153 @@
+package registry
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testRecords = []Record{
+	{LicenceKey: "a", Name: "Jane Doe", Email: "jane@example.com", Product: "editor", ExpiryDate: "2025-01-31", Format: "json"},
+	{LicenceKey: "b", Name: "John Roe", Email: "john@acme.test", Product: "Viewer", ExpiryDate: "2025-06-30", Format: "jws"},
+	{LicenceKey: "a", Name: "Jane Doe", Email: "jane@example.com", Product: "editor", ExpiryDate: "2026-01-31", Format: "json"},
+}
+
+func openTestRegistry(t *testing.T) (*Registry, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "nested", DEFAULT_FILE_NAME)
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, record := range testRecords {
+		record.SignedAt = signedAt.Add(time.Duration(i) * time.Hour)
+		added, err := r.Add(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added.Id != uint64(i+1) {
+			t.Fatalf("record %d got id %d", i, added.Id)
+		}
+	}
+	return r, path
+}
+
+func licenceKeys(records []Record) string {
+	var keys []string
+	for _, record := range records {
+		keys = append(keys, record.LicenceKey)
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestSearch(t *testing.T) {
+	r, _ := openTestRegistry(t)
+	defer r.Close()
+
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{"everything in signing order", Query{}, "a,b,a"},
+		{"customer name ignoring case", Query{Customer: "JOHN"}, "b"},
+		{"customer email", Query{Customer: "example.com"}, "a,a"},
+		{"product ignoring case", Query{Product: "viewer"}, "b"},
+		{"product is not a substring", Query{Product: "edit"}, ""},
+		{"expires after inclusive", Query{ExpiresAfter: "2025-06-30"}, "b,a"},
+		{"expires before inclusive", Query{ExpiresBefore: "2025-06-30"}, "a,b"},
+		{"expiry range", Query{ExpiresAfter: "2025-02-01", ExpiresBefore: "2025-12-31"}, "b"},
+		{"combined", Query{Customer: "jane", ExpiresAfter: "2026-01-01"}, "a"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, err := r.Search(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := licenceKeys(records); got != test.want {
+				t.Fatalf("Search(%+v) = %s, want %s", test.query, got, test.want)
+			}
+		})
+	}
+
+	_, err := r.Search(Query{ExpiresBefore: "31/01/2025"})
+	if err == nil {
+		t.Fatal("Search accepted an invalid date")
+	}
+}
+
+func TestFind(t *testing.T) {
+	r, path := openTestRegistry(t)
+	r.Close()
+
+	// Records outlive the process that added them.
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	found, err := r.Find("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0].Id != 1 || found[1].Id != 3 || found[1].ExpiryDate != "2026-01-31" {
+		t.Fatalf("Find(a) = %+v, want records 1 and 3", found)
+	}
+	_, err = r.Find("missing")
+	if err == nil {
+		t.Fatal("Find returned a licence that was never recorded")
+	}
+}
+
+func TestExport(t *testing.T) {
+	r, _ := openTestRegistry(t)
+	defer r.Close()
+	records, err := r.Search(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Export(records, CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(records)+1 || !reflect.DeepEqual(rows[0], csvHeader) {
+		t.Fatalf("CSV export has %d rows and header %v", len(rows), rows[0])
+	}
+	if want := []string{"2", "b", "John Roe", "john@acme.test", "Viewer", "", "", "", "2025-06-30", "", "jws", "", "", "2024-01-01T13:00:00Z"}; !reflect.DeepEqual(rows[2], want) {
+		t.Fatalf("CSV row = %q, want %q", rows[2], want)
+	}
+
+	data, err = Export(records, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported []Record
+	err = json.Unmarshal(data, &exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(exported, records) {
+		t.Fatalf("JSON export = %+v, want %+v", exported, records)
+	}
+
+	data, err = Export(nil, JSON)
+	if err != nil || strings.TrimSpace(string(data)) != "[]" {
+		t.Fatalf("empty JSON export = %q, %v, want []", data, err)
+	}
+	_, err = Export(records, ExportFormat(-1))
+	if err == nil {
+		t.Fatal("Export accepted an unknown format")
+	}
+}
@@ -0,0 +1,143 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Share is one point of the secret sharing polynomials. Index is the x
+// coordinate shared by every byte of Data.
+type Share struct {
+	Index byte
+	Data  []byte
+}
+
+// Arithmetic is over GF(2^8) with the AES reduction polynomial x^8+x^4+x^3+x+1.
+var expTable, logTable = func() (exp [255]byte, log [256]byte) {
+	x := byte(1)
+	for i := range exp {
+		exp[i] = x
+		log[x] = byte(i)
+		// multiply by the generator 3
+		x ^= mulNoTable(x, 2)
+	}
+	return
+}()
+
+func mulNoTable(a, b byte) byte {
+	var product byte
+	for b > 0 {
+		if b&1 == 1 {
+			product ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return product
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])+int(logTable[b]))%255]
+}
+
+func div(a, b byte) byte {
+	if b == 0 {
+		panic("shamir: division by zero")
+	}
+	if a == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])-int(logTable[b])+255)%255]
+}
+
+// evaluate computes the polynomial with the given coefficients at x using
+// Horner's method. coefficients[0] is the constant term.
+func evaluate(coefficients []byte, x byte) byte {
+	var result byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result = mul(result, x) ^ coefficients[i]
+	}
+	return result
+}
+
+// Split divides secret into n shares so that any threshold of them recover it.
+func Split(secret []byte, n, threshold int) ([]Share, error) {
+	if threshold < 2 {
+		return nil, errors.New("threshold must be at least 2")
+	}
+	if n < threshold {
+		return nil, errors.New("number of shares cannot be less than the threshold")
+	}
+	if n > 255 {
+		return nil, errors.New("cannot create more than 255 shares")
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("cannot split an empty secret")
+	}
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{Index: byte(i + 1), Data: make([]byte, len(secret))}
+	}
+	coefficients := make([]byte, threshold)
+	for position, b := range secret {
+		coefficients[0] = b
+		_, err := rand.Read(coefficients[1:])
+		if err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			shares[i].Data[position] = evaluate(coefficients, shares[i].Index)
+		}
+	}
+	clear(coefficients)
+	return shares, nil
+}
+
+// Combine recovers the secret from shares using Lagrange interpolation at
+// x = 0. It cannot detect whether enough shares were supplied; callers must
+// verify the result.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least 2 shares are required")
+	}
+	size := len(shares[0].Data)
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if share.Index == 0 {
+			return nil, errors.New("share index cannot be 0")
+		}
+		if seen[share.Index] {
+			return nil, fmt.Errorf("share %d supplied more than once", share.Index)
+		}
+		seen[share.Index] = true
+		if len(share.Data) != size {
+			return nil, errors.New("shares have different lengths")
+		}
+	}
+
+	secret := make([]byte, size)
+	for i, share := range shares {
+		// Lagrange basis polynomial for this share evaluated at 0. In
+		// GF(2^8) subtraction is xor, so (0 - x_j) / (x_i - x_j) is x_j / (x_i ^ x_j).
+		basis := byte(1)
+		for j, other := range shares {
+			if i == j {
+				continue
+			}
+			basis = mul(basis, div(other.Index, share.Index^other.Index))
+		}
+		for position, y := range share.Data {
+			secret[position] ^= mul(y, basis)
+		}
+	}
+	return secret, nil
+}
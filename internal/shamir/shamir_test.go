@@ -0,0 +1,119 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/bits"
+	"testing"
+)
+
+func testSecret(t *testing.T) []byte {
+	t.Helper()
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+// subsets returns every subset of shares, one per bit mask.
+func subsets(shares []Share) map[uint][]Share {
+	result := make(map[uint][]Share)
+	for mask := uint(1); mask < 1<<len(shares); mask++ {
+		var subset []Share
+		for i, share := range shares {
+			if mask&(1<<i) != 0 {
+				subset = append(subset, share)
+			}
+		}
+		result[mask] = subset
+	}
+	return result
+}
+
+func TestCombineSubsets(t *testing.T) {
+	for n := 2; n <= 6; n++ {
+		for threshold := 2; threshold <= n; threshold++ {
+			secret := testSecret(t)
+			shares, err := Split(secret, n, threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for mask, subset := range subsets(shares) {
+				count := bits.OnesCount(mask)
+				if count < 2 {
+					_, err := Combine(subset)
+					if err == nil {
+						t.Errorf("%d-of-%d: combining a single share succeeded", threshold, n)
+					}
+					continue
+				}
+				recovered, err := Combine(subset)
+				if err != nil {
+					t.Fatalf("%d-of-%d shares %b: %v", threshold, n, mask, err)
+				}
+				// A random 32 byte secret is recovered by chance with
+				// probability 2^-256.
+				if enough := count >= threshold; enough != bytes.Equal(recovered, secret) {
+					t.Errorf("%d-of-%d shares %b: recovered = %t, want %t", threshold, n, mask, !enough, enough)
+				}
+			}
+		}
+	}
+}
+
+func TestCombineInvalidShares(t *testing.T) {
+	shares, err := Split(testSecret(t), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string][]Share{
+		"duplicate":  {shares[0], shares[0]},
+		"zero index": {shares[0], {Index: 0, Data: shares[1].Data}},
+		"length":     {shares[0], {Index: shares[1].Index, Data: shares[1].Data[1:]}},
+		"none":       nil,
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Combine(c)
+			if err == nil {
+				t.Fatal("invalid shares combined")
+			}
+		})
+	}
+}
+
+func TestSplitInvalid(t *testing.T) {
+	secret := testSecret(t)
+	cases := map[string]struct {
+		secret       []byte
+		n, threshold int
+	}{
+		"threshold 1":       {secret, 3, 1},
+		"n below threshold": {secret, 2, 3},
+		"too many shares":   {secret, 256, 2},
+		"empty secret":      {nil, 3, 2},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Split(c.secret, c.n, c.threshold)
+			if err == nil {
+				t.Fatal("invalid split succeeded")
+			}
+		})
+	}
+}
+
+func TestMultiplicationTables(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			if got, want := mul(byte(a), byte(b)), mulNoTable(byte(a), byte(b)); got != want {
+				t.Fatalf("mul(%d, %d) = %d, want %d", a, b, got, want)
+			}
+			if b != 0 && div(mul(byte(a), byte(b)), byte(b)) != byte(a) {
+				t.Fatalf("div(mul(%d, %d), %d) != %d", a, b, b, a)
+			}
+		}
+	}
+}
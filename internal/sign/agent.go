@@ -0,0 +1,98 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// AgentSigner signs with a key held by an ssh-agent. It is an
+// ssh.AlgorithmSigner for SSH signatures and a crypto.Signer for Ed25519
+// keys, the only agent keys that sign the message as given rather than
+// hashing it themselves.
+type AgentSigner struct {
+	ssh.AlgorithmSigner
+	public crypto.PublicKey
+	conn   net.Conn
+}
+
+func (s *AgentSigner) Close() error {
+	return s.conn.Close()
+}
+
+func (s *AgentSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs message with an Ed25519 agent key. RSA and ECDSA agent keys
+// cannot sign a digest computed by the caller.
+func (s *AgentSigner) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if _, ok := s.Public().(ed25519.PublicKey); !ok || opts.HashFunc() != 0 {
+		return nil, fmt.Errorf("ssh-agent %s keys hash the message themselves and can only be used for SSH signatures, use an Ed25519 key", s.PublicKey().Type())
+	}
+	signature, err := s.SignWithAlgorithm(rand, message, ssh.KeyAlgoED25519)
+	if err != nil {
+		return nil, err
+	}
+	return signature.Blob, nil
+}
+
+func matchesFingerprint(key ssh.PublicKey, fingerprint string) bool {
+	if strings.HasPrefix(fingerprint, "SHA256:") {
+		return ssh.FingerprintSHA256(key) == fingerprint
+	}
+	return ssh.FingerprintLegacyMD5(key) == strings.TrimPrefix(fingerprint, "MD5:")
+}
+
+// NewAgentSigner connects to the ssh-agent listening on socket, or
+// $SSH_AUTH_SOCK when socket is empty, and selects the key matching
+// fingerprint in either SHA256:... or MD5 form.
+func NewAgentSigner(socket, fingerprint string) (*AgentSigner, error) {
+	if socket == "" {
+		socket = os.Getenv("SSH_AUTH_SOCK")
+	}
+	if socket == "" {
+		return nil, errors.New("no ssh-agent socket specified and SSH_AUTH_SOCK is not set")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ssh-agent: %w", err)
+	}
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to list ssh-agent keys: %w", err)
+	}
+	for _, signer := range signers {
+		if !matchesFingerprint(signer.PublicKey(), fingerprint) {
+			continue
+		}
+		algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+		if !ok {
+			conn.Close()
+			return nil, errors.New("ssh-agent signer does not support signature algorithm selection")
+		}
+		// Agent keys are raw wire blobs, parse them to reach the crypto key.
+		public, err := ssh.ParsePublicKey(signer.PublicKey().Marshal())
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("invalid ssh-agent key: %w", err)
+		}
+		cryptoPublic, ok := public.(ssh.CryptoPublicKey)
+		if !ok {
+			conn.Close()
+			return nil, fmt.Errorf("unsupported ssh-agent key type '%s'", public.Type())
+		}
+		return &AgentSigner{AlgorithmSigner: algorithmSigner, public: cryptoPublic.CryptoPublicKey(), conn: conn}, nil
+	}
+	conn.Close()
+	return nil, fmt.Errorf("ssh-agent holds no key with fingerprint '%s'", fingerprint)
+}
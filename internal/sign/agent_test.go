@@ -0,0 +1,126 @@
+package sign
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// startAgent serves an in-process ssh-agent holding keys and returns its
+// socket.
+func startAgent(t *testing.T, keys ...any) string {
+	t.Helper()
+	keyring := agent.NewKeyring()
+	for _, key := range keys {
+		err := keyring.Add(agent.AddedKey{PrivateKey: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	return socket
+}
+
+func fingerprint(t *testing.T, public any) string {
+	t.Helper()
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ssh.FingerprintSHA256(sshPublic)
+}
+
+func TestAgentSignerEd25519(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := startAgent(t, private)
+
+	signer, err := NewAgentSigner(socket, fingerprint(t, public))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Close()
+
+	if !public.Equal(signer.Public()) {
+		t.Fatal("agent signer returned a different public key")
+	}
+	message := []byte("licence")
+	signature, err := SignMessage(signer, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = VerifySignature(signature, message, public)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+}
+
+func TestAgentSignerRSA(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := startAgent(t, private)
+
+	signer, err := NewAgentSigner(socket, fingerprint(t, &private.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Close()
+
+	// The agent hashes messages itself, so it cannot sign a digest.
+	_, err = SignMessage(signer, []byte("licence"))
+	if err == nil {
+		t.Fatal("RSA agent key signed a digest")
+	}
+	signature, err := signer.SignWithAlgorithm(rand.Reader, []byte("licence"), ssh.KeyAlgoRSASHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = signer.PublicKey().Verify([]byte("licence"), signature)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+}
+
+func TestAgentSignerUnknownKey(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := startAgent(t, private)
+
+	_, err = NewAgentSigner(socket, fingerprint(t, other))
+	if err == nil {
+		t.Fatal("agent signer opened a key the agent does not hold")
+	}
+}
@@ -6,62 +6,142 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
 	"encoding/asn1"
 	"errors"
 	"fmt"
 	"math/big"
+
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const (
+	RSA_SHA256   string = "RSA-SHA256"
+	ECDSA_SHA256 string = "ECDSA-SHA256"
+	ED25519      string = "Ed25519"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrInvalidPublicKey = errors.New("invalid public key")
 )
 
-func verifyRSASignature(signature, data []byte, key *rsa.PublicKey) error {
-	hashed := sha256.Sum256(data)
-	err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature)
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+func digest(data []byte, hash crypto.Hash) ([]byte, error) {
+	if !hash.Available() {
+		return nil, fmt.Errorf("hash function %v is not available", hash)
+	}
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+func verifyRSASignature(signature, data []byte, key *rsa.PublicKey, hash crypto.Hash) error {
+	hashed, err := digest(data, hash)
+	if err != nil {
+		return err
+	}
+	err = rsa.VerifyPKCS1v15(key, hash, hashed, signature)
 	if err != nil {
-		return fmt.Errorf("signature verification failed: %w", err)
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
 	}
 	return nil
 }
 
-func verifyECDSASignature(signature, data []byte, key *ecdsa.PublicKey) error {
-	hashed := sha256.Sum256(data)
-	var ecdsaSignature struct {
-		R, S *big.Int
+func verifyECDSASignature(signature, data []byte, key *ecdsa.PublicKey, hash crypto.Hash) error {
+	hashed, err := digest(data, hash)
+	if err != nil {
+		return err
 	}
-	_, err := asn1.Unmarshal(signature, &ecdsaSignature)
+	var sig ecdsaSignature
+	_, err = asn1.Unmarshal(signature, &sig)
 	if err != nil {
-		return fmt.Errorf("error unmarshaling signature: %v", err)
+		return fmt.Errorf("%w: error unmarshaling signature: %v", ErrInvalidSignature, err)
 	}
-	if !ecdsa.Verify(key, hashed[:], ecdsaSignature.R, ecdsaSignature.S) {
-		return fmt.Errorf("signature verification failed")
+	if !ecdsa.Verify(key, hashed, sig.R, sig.S) {
+		return ErrInvalidSignature
 	}
 	return nil
 }
 
 func verifyEDSignature(signature, data []byte, key ed25519.PublicKey) error {
 	if !ed25519.Verify(key, data, signature) {
-		return errors.New("invalid signature")
+		return ErrInvalidSignature
 	}
 	return nil
 }
 
-func VerifySignature(signature, data []byte, key crypto.PublicKey) error {
+func VerifySignatureHash(signature, data []byte, key crypto.PublicKey, hash crypto.Hash) error {
 	switch key := key.(type) {
 	case *rsa.PublicKey:
-		return verifyRSASignature(signature, data, key)
+		return verifyRSASignature(signature, data, key, hash)
 	case *ecdsa.PublicKey:
-		return verifyECDSASignature(signature, data, key)
+		return verifyECDSASignature(signature, data, key, hash)
 	case ed25519.PublicKey:
 		return verifyEDSignature(signature, data, key)
 	default:
-		return errors.New("invalid public key")
+		return ErrInvalidPublicKey
+	}
+}
+
+func VerifySignature(signature, data []byte, key crypto.PublicKey) error {
+	return VerifySignatureHash(signature, data, key, crypto.SHA256)
+}
+
+func Algorithm(key crypto.PublicKey) (string, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return RSA_SHA256, nil
+	case *ecdsa.PublicKey:
+		return ECDSA_SHA256, nil
+	case ed25519.PublicKey:
+		return ED25519, nil
+	default:
+		return "", ErrInvalidPublicKey
+	}
+}
+
+func SignMessageHash(signer crypto.Signer, data []byte, hash crypto.Hash) ([]byte, error) {
+	// Ed25519 signs the message itself rather than a digest of it.
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		return signer.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	hashed, err := digest(data, hash)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Sign(rand.Reader, hashed, hash)
+}
+
+func SignMessage(signer crypto.Signer, data []byte) ([]byte, error) {
+	return SignMessageHash(signer, data, crypto.SHA256)
+}
+
+// ECDSAToRaw converts an ASN.1 encoded ECDSA signature into the fixed size
+// r||s form used by JOSE and COSE.
+func ECDSAToRaw(signature []byte, key *ecdsa.PublicKey) ([]byte, error) {
+	var sig ecdsaSignature
+	_, err := asn1.Unmarshal(signature, &sig)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling signature: %v", err)
 	}
+	size := (key.Curve.Params().BitSize + 7) / 8
+	raw := make([]byte, 2*size)
+	sig.R.FillBytes(raw[:size])
+	sig.S.FillBytes(raw[size:])
+	return raw, nil
 }
 
-func SignMessage(key crypto.PrivateKey, data []byte) ([]byte, error) {
-	signer, ok := key.(crypto.Signer)
-	if !ok {
-		return nil, errors.New("private key is not a signer")
+func ECDSAFromRaw(raw []byte, key *ecdsa.PublicKey) ([]byte, error) {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	if len(raw) != 2*size {
+		return nil, fmt.Errorf("%w: length %d, expected %d", ErrInvalidSignature, len(raw), 2*size)
 	}
-	hashed := sha256.Sum256(data)
-	return signer.Sign(rand.Reader, hashed[:], crypto.SHA256)
+	return asn1.Marshal(ecdsaSignature{
+		R: new(big.Int).SetBytes(raw[:size]),
+		S: new(big.Int).SetBytes(raw[size:]),
+	})
 }
@@ -0,0 +1,136 @@
+package signer
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+)
+
+// The exec backend runs an external helper once per operation. The spec is
+// exec:<path>, where the path is used as is and may contain spaces, or
+// exec:["<path>", "<argument>", ...] to pass arguments to the helper. The helper
+// reads a single JSON request from stdin and writes a single JSON response
+// to stdout. Every request carries "version": 1 and an "operation":
+//
+//	{"version": 1, "operation": "public-key"}
+//	  -> {"public_key": "-----BEGIN PUBLIC KEY-----\n..."}
+//
+//	{"version": 1, "operation": "sign", "hash": "SHA-256", "digest": "<base64>"}
+//	  -> {"signature": "<base64>"}
+//
+// The public key is a PKIX PEM block. For sign, digest holds the message
+// digest computed with hash; when hash is empty the key is Ed25519 and
+// digest holds the whole message. Signatures use the encoding of Go's
+// crypto.Signer: PKCS #1 v1.5 for RSA, ASN.1 DER for ECDSA and raw bytes for
+// Ed25519. A helper reports failure with {"error": "message"} or a non-zero
+// exit status. Signatures are checked against the helper's public key before
+// they are used.
+const (
+	execProtocolVersion = 1
+
+	publicKeyOperation string = "public-key"
+	signOperation      string = "sign"
+)
+
+type execRequest struct {
+	Version   int    `json:"version"`
+	Operation string `json:"operation"`
+	Hash      string `json:"hash,omitempty"`
+	Digest    string `json:"digest,omitempty"`
+}
+
+type execResponse struct {
+	PublicKey string `json:"public_key,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type execSigner struct {
+	command []string
+	public  crypto.PublicKey
+}
+
+func openExec(argument string) (crypto.Signer, error) {
+	s := &execSigner{command: []string{argument}}
+	if strings.HasPrefix(argument, "[") {
+		s.command = nil
+		err := json.Unmarshal([]byte(argument), &s.command)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signer helper command '%s': %w", argument, err)
+		}
+	}
+	if len(s.command) == 0 || s.command[0] == "" {
+		return nil, errors.New("no signer helper specified")
+	}
+	response, err := s.call(execRequest{Operation: publicKeyOperation})
+	if err != nil {
+		return nil, err
+	}
+	s.public, err = key.ParsePublicKey([]byte(response.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("signer helper returned an invalid public key: %w", err)
+	}
+	return s, nil
+}
+
+func (s *execSigner) call(request execRequest) (execResponse, error) {
+	request.Version = execProtocolVersion
+	input, err := json.Marshal(request)
+	if err != nil {
+		return execResponse{}, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(s.command[0], s.command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	var response execResponse
+	err = json.Unmarshal(stdout.Bytes(), &response)
+	if response.Error != "" {
+		return execResponse{}, fmt.Errorf("signer helper: %s", response.Error)
+	}
+	if runErr != nil {
+		return execResponse{}, fmt.Errorf("signer helper failed: %w: %s", runErr, strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		return execResponse{}, fmt.Errorf("signer helper returned an invalid response: %w", err)
+	}
+	return response, nil
+}
+
+func (s *execSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+func (s *execSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if _, ok := opts.(interface{ SaltLength() int }); ok {
+		return nil, errors.New("signer helper does not support RSA-PSS")
+	}
+	request := execRequest{Operation: signOperation, Digest: base64.StdEncoding.EncodeToString(digest)}
+	if opts.HashFunc() != 0 {
+		request.Hash = opts.HashFunc().String()
+	}
+	response, err := s.call(request)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(response.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("signer helper returned an invalid signature: %w", err)
+	}
+	err = verifyDigest(s.public, digest, signature, opts.HashFunc())
+	if err != nil {
+		return nil, fmt.Errorf("signer helper signature does not verify with its public key: %w", err)
+	}
+	return signature, nil
+}
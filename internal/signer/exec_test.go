@@ -0,0 +1,212 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+)
+
+// The test binary doubles as the exec signer helper when helperKeyEnv is
+// set. The helper signs with that key, and its first argument selects how it
+// misbehaves.
+const helperKeyEnv = "FILE_SIGNER_TEST_HELPER_KEY"
+
+func TestMain(m *testing.M) {
+	if privateKey := os.Getenv(helperKeyEnv); privateKey != "" {
+		mode := "good"
+		if len(os.Args) > 1 {
+			mode = os.Args[1]
+		}
+		os.Exit(runHelper(privateKey, mode))
+	}
+	os.Exit(m.Run())
+}
+
+var helperHashes = map[string]crypto.Hash{
+	crypto.SHA256.String(): crypto.SHA256,
+	crypto.SHA384.String(): crypto.SHA384,
+	crypto.SHA512.String(): crypto.SHA512,
+}
+
+func runHelper(privateKey, mode string) int {
+	switch mode {
+	case "exit":
+		fmt.Fprintln(os.Stderr, "helper crashed")
+		return 3
+	case "garbage":
+		fmt.Println("not json")
+		return 0
+	}
+
+	private, err := key.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	signer := private.(crypto.Signer)
+	var request execRequest
+	err = json.NewDecoder(os.Stdin).Decode(&request)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var response execResponse
+	switch request.Operation {
+	case publicKeyOperation:
+		_, public, err := key.MarshalKeyPair(private, signer.Public())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		response.PublicKey = string(public)
+	case signOperation:
+		if mode == "refuse" {
+			response.Error = "key is locked"
+			break
+		}
+		if mode == "wrong-key" {
+			_, signer, _ = ed25519.GenerateKey(rand.Reader)
+		}
+		digest, _ := base64.StdEncoding.DecodeString(request.Digest)
+		signature, err := signer.Sign(rand.Reader, digest, helperHashes[request.Hash])
+		if err != nil {
+			response.Error = err.Error()
+			break
+		}
+		response.Signature = base64.StdEncoding.EncodeToString(signature)
+	}
+	json.NewEncoder(os.Stdout).Encode(response)
+	return 0
+}
+
+// setHelperKey makes the helper sign with private.
+func setHelperKey(t *testing.T, private crypto.PrivateKey) {
+	privateBytes, _, err := key.MarshalKeyPair(private, private.(crypto.Signer).Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(helperKeyEnv, string(privateBytes))
+}
+
+// helperPath links the test binary into a directory whose name has a space.
+func helperPath(t *testing.T) string {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "signer helper")
+	err = os.Mkdir(dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "helper")
+	err = os.Symlink(executable, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func helperCommand(t *testing.T, path string, args ...string) string {
+	command, err := json.Marshal(append([]string{path}, args...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(command)
+}
+
+func TestExecSign(t *testing.T) {
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := helperPath(t)
+	message := []byte("licence")
+	digest := sha256.Sum256(message)
+
+	tests := []struct {
+		name    string
+		key     crypto.Signer
+		spec    string
+		digest  []byte
+		opts    crypto.SignerOpts
+		wantErr string
+	}{
+		{"ed25519", ed25519Key, path, message, crypto.Hash(0), ""},
+		{"ecdsa with arguments", ecdsaKey, helperCommand(t, path, "good"), digest[:], crypto.SHA256, ""},
+		{"wrong key", ed25519Key, helperCommand(t, path, "wrong-key"), message, crypto.Hash(0), "does not verify"},
+		{"helper error", ed25519Key, helperCommand(t, path, "refuse"), message, crypto.Hash(0), "key is locked"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setHelperKey(t, test.key)
+			s, err := openExec(test.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !s.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(test.key.Public()) {
+				t.Fatal("helper published the wrong public key")
+			}
+
+			signature, err := s.Sign(nil, test.digest, test.opts)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Sign error = %v, want one containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = verifyDigest(test.key.Public(), test.digest, signature, test.opts.HashFunc()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestExecOpenFailures(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setHelperKey(t, private)
+	path := helperPath(t)
+
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{"non-zero exit", helperCommand(t, path, "exit"), "helper crashed"},
+		{"garbage output", helperCommand(t, path, "garbage"), "invalid response"},
+		{"missing helper", filepath.Join(t.TempDir(), "missing"), "signer helper failed"},
+		{"empty command", "[]", "no signer helper"},
+		{"invalid command", "[/bin/helper", "invalid signer helper command"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := openExec(test.spec)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("openExec error = %v, want one containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/eslam-allam/file-signer/internal/fs"
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/sign"
+)
+
+const (
+	FILE_BACKEND  string = "file"
+	EXEC_BACKEND  string = "exec"
+	VAULT_BACKEND string = "vault"
+	AGENT_BACKEND string = "agent"
+)
+
+// Open returns the signer described by spec, which takes the form
+// backend:argument, for example file:private.key, exec:/usr/bin/helper,
+// vault:licence-key or agent:SHA256:<fingerprint> for a key held by the
+// ssh-agent at $SSH_AUTH_SOCK.
+func Open(spec string) (crypto.Signer, error) {
+	backend, argument, found := strings.Cut(spec, ":")
+	if !found || argument == "" {
+		return nil, fmt.Errorf("invalid signer '%s', expected backend:argument", spec)
+	}
+	switch backend {
+	case FILE_BACKEND:
+		return openFile(argument)
+	case EXEC_BACKEND:
+		return openExec(argument)
+	case VAULT_BACKEND:
+		return openVault(argument)
+	case AGENT_BACKEND:
+		return sign.NewAgentSigner("", argument)
+	default:
+		return nil, fmt.Errorf("unknown signer backend '%s'", backend)
+	}
+}
+
+func openFile(path string) (crypto.Signer, error) {
+	privateKey, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	private, err := key.ParsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key is not a signer")
+	}
+	return signer, nil
+}
+
+// verifyDigest checks a signature returned by a remote backend against its
+// public key, using the encodings of crypto.Signer.
+func verifyDigest(public crypto.PublicKey, digest, signature []byte, hash crypto.Hash) error {
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(public, hash, digest, signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(public, digest, signature) {
+			return errors.New("invalid ECDSA signature")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(public, digest, signature) {
+			return errors.New("invalid Ed25519 signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", public)
+	}
+}
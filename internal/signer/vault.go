@@ -0,0 +1,282 @@
+package signer
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The vault backend signs with a key held by HashiCorp Vault's transit
+// secrets engine. The spec is vault:<key>[@<version>] and the connection is
+// configured through the environment:
+//
+//	VAULT_ADDR              Vault address (default http://127.0.0.1:8200)
+//	VAULT_TOKEN             token used to authenticate
+//	VAULT_ROLE_ID           AppRole role ID, used when VAULT_TOKEN is unset
+//	VAULT_SECRET_ID         AppRole secret ID
+//	VAULT_APPROLE_MOUNT     AppRole auth mount (default approle)
+//	VAULT_TRANSIT_MOUNT     transit engine mount (default transit)
+//	VAULT_NAMESPACE         Vault Enterprise namespace
+const (
+	defaultVaultAddress  string = "http://127.0.0.1:8200"
+	defaultTransitMount  string = "transit"
+	defaultAppRoleMount  string = "approle"
+	vaultSignaturePrefix string = "vault:v"
+)
+
+var vaultHashAlgorithms = map[crypto.Hash]string{
+	crypto.SHA256: "sha2-256",
+	crypto.SHA384: "sha2-384",
+	crypto.SHA512: "sha2-512",
+}
+
+type vaultSigner struct {
+	client    *http.Client
+	address   string
+	token     string
+	namespace string
+	mount     string
+	name      string
+	version   int
+	public    crypto.PublicKey
+}
+
+type vaultError struct {
+	Errors []string `json:"errors"`
+}
+
+type vaultKey struct {
+	Data struct {
+		Type          string `json:"type"`
+		LatestVersion int    `json:"latest_version"`
+		Keys          map[string]struct {
+			PublicKey string `json:"public_key"`
+		} `json:"keys"`
+	} `json:"data"`
+}
+
+type vaultSignRequest struct {
+	Input              string `json:"input"`
+	KeyVersion         int    `json:"key_version,omitempty"`
+	HashAlgorithm      string `json:"hash_algorithm,omitempty"`
+	Prehashed          bool   `json:"prehashed,omitempty"`
+	SignatureAlgorithm string `json:"signature_algorithm,omitempty"`
+}
+
+type vaultSignResponse struct {
+	Data struct {
+		Signature string `json:"signature"`
+	} `json:"data"`
+}
+
+type vaultLoginResponse struct {
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+}
+
+func envOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func openVault(argument string) (crypto.Signer, error) {
+	name, version, hasVersion := strings.Cut(argument, "@")
+	s := &vaultSigner{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		address:   strings.TrimSuffix(envOrDefault("VAULT_ADDR", defaultVaultAddress), "/"),
+		token:     os.Getenv("VAULT_TOKEN"),
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+		mount:     strings.Trim(envOrDefault("VAULT_TRANSIT_MOUNT", defaultTransitMount), "/"),
+		name:      name,
+	}
+	if hasVersion {
+		var err error
+		s.version, err = strconv.Atoi(version)
+		if err != nil || s.version < 1 {
+			return nil, fmt.Errorf("invalid vault key version '%s'", version)
+		}
+	}
+
+	if s.token == "" {
+		err := s.loginAppRole()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := s.fetchPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *vaultSigner) do(method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	request, err := http.NewRequest(method, s.address+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		request.Header.Set("X-Vault-Token", s.token)
+	}
+	if s.namespace != "" {
+		request.Header.Set("X-Vault-Namespace", s.namespace)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("vault request failed: %w", err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read vault response: %w", err)
+	}
+	if response.StatusCode/100 != 2 {
+		var vaultErr vaultError
+		if json.Unmarshal(data, &vaultErr) == nil && len(vaultErr.Errors) != 0 {
+			return fmt.Errorf("vault returned %s: %s", response.Status, strings.Join(vaultErr.Errors, "; "))
+		}
+		return fmt.Errorf("vault returned %s", response.Status)
+	}
+	err = json.Unmarshal(data, result)
+	if err != nil {
+		return fmt.Errorf("invalid vault response: %w", err)
+	}
+	return nil
+}
+
+func (s *vaultSigner) loginAppRole() error {
+	roleId, secretId := os.Getenv("VAULT_ROLE_ID"), os.Getenv("VAULT_SECRET_ID")
+	if roleId == "" || secretId == "" {
+		return errors.New("set VAULT_TOKEN or both VAULT_ROLE_ID and VAULT_SECRET_ID to authenticate with vault")
+	}
+	mount := strings.Trim(envOrDefault("VAULT_APPROLE_MOUNT", defaultAppRoleMount), "/")
+	var login vaultLoginResponse
+	err := s.do(http.MethodPost, "auth/"+mount+"/login", map[string]string{"role_id": roleId, "secret_id": secretId}, &login)
+	if err != nil {
+		return fmt.Errorf("vault AppRole login failed: %w", err)
+	}
+	if login.Auth.ClientToken == "" {
+		return errors.New("vault AppRole login returned no token")
+	}
+	s.token = login.Auth.ClientToken
+	return nil
+}
+
+func (s *vaultSigner) fetchPublicKey() error {
+	var k vaultKey
+	err := s.do(http.MethodGet, s.mount+"/keys/"+url.PathEscape(s.name), nil, &k)
+	if err != nil {
+		return err
+	}
+	version := s.version
+	if version == 0 {
+		version = k.Data.LatestVersion
+	}
+	entry, ok := k.Data.Keys[strconv.Itoa(version)]
+	if !ok {
+		return fmt.Errorf("vault key '%s' has no version %d", s.name, version)
+	}
+
+	if k.Data.Type == "ed25519" {
+		raw, err := base64.StdEncoding.DecodeString(entry.PublicKey)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return fmt.Errorf("vault returned an invalid ed25519 public key for '%s'", s.name)
+		}
+		s.public = ed25519.PublicKey(raw)
+		return nil
+	}
+	block, _ := pem.Decode([]byte(entry.PublicKey))
+	if block == nil {
+		return fmt.Errorf("vault key '%s' of type '%s' has no public key", s.name, k.Data.Type)
+	}
+	s.public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("vault returned an invalid public key for '%s': %w", s.name, err)
+	}
+	return nil
+}
+
+func (s *vaultSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign asks transit to sign digest. Transit returns signatures as
+// vault:v<version>:<base64>, whose payload already matches crypto.Signer's
+// encodings when RSA uses pkcs1v15 and ECDSA uses the default asn1 marshaling.
+//
+// The signature is checked locally against the public key fetched when the
+// signer was opened rather than with transit/verify. That key is the one
+// verifiers are given, so a local check proves the signature will verify for
+// them, and it catches a different key version, padding or encoding being
+// used by Vault, which transit/verify would accept as its own output.
+func (s *vaultSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if _, ok := opts.(interface{ SaltLength() int }); ok {
+		return nil, errors.New("vault signer does not support RSA-PSS")
+	}
+	request := vaultSignRequest{
+		Input:      base64.StdEncoding.EncodeToString(digest),
+		KeyVersion: s.version,
+	}
+	if opts.HashFunc() != 0 {
+		hash, ok := vaultHashAlgorithms[opts.HashFunc()]
+		if !ok {
+			return nil, fmt.Errorf("vault does not support hash %v", opts.HashFunc())
+		}
+		request.HashAlgorithm = hash
+		request.Prehashed = true
+		request.SignatureAlgorithm = "pkcs1v15"
+	}
+
+	var response vaultSignResponse
+	err := s.do(http.MethodPost, s.mount+"/sign/"+url.PathEscape(s.name), request, &response)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := decodeVaultSignature(response.Data.Signature)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyDigest(s.public, digest, signature, opts.HashFunc())
+	if err != nil {
+		return nil, fmt.Errorf("vault signature does not verify with key '%s': %w", s.name, err)
+	}
+	return signature, nil
+}
+
+func decodeVaultSignature(signature string) ([]byte, error) {
+	if !strings.HasPrefix(signature, vaultSignaturePrefix) {
+		return nil, fmt.Errorf("unexpected vault signature '%s'", signature)
+	}
+	_, encoded, found := strings.Cut(strings.TrimPrefix(signature, vaultSignaturePrefix), ":")
+	if !found {
+		return nil, fmt.Errorf("unexpected vault signature '%s'", signature)
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
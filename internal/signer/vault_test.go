@@ -0,0 +1,262 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testVaultToken  = "s.test-token"
+	testVaultRoleId = "role"
+	testVaultSecret = "secret"
+)
+
+// fakeVault serves the transit key and sign endpoints for a single key and
+// the AppRole login endpoint. signingKey signs requests, which lets tests
+// make Vault answer with a key other than the one it publishes.
+type fakeVault struct {
+	t          *testing.T
+	keyType    string
+	key        crypto.Signer
+	signingKey crypto.Signer
+	lock       sync.Mutex
+	requests   []vaultSignRequest
+}
+
+func (v *fakeVault) publicKey() (string, error) {
+	if public, ok := v.key.Public().(ed25519.PublicKey); ok {
+		return base64.StdEncoding.EncodeToString(public), nil
+	}
+	der, err := x509.MarshalPKIXPublicKey(v.key.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// fail reports err from the handler goroutine, where t.Fatal may not be
+// called, and answers with a server error.
+func (v *fakeVault) fail(w http.ResponseWriter, err error) {
+	v.t.Error(err)
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
+func (v *fakeVault) signRequests() []vaultSignRequest {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return slices.Clone(v.requests)
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeJSON := func(value any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(value)
+	}
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var login map[string]string
+		json.NewDecoder(r.Body).Decode(&login)
+		if login["role_id"] != testVaultRoleId || login["secret_id"] != testVaultSecret {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(vaultError{Errors: []string{"invalid role or secret ID"}})
+			return
+		}
+		writeJSON(map[string]any{"auth": map[string]string{"client_token": testVaultToken}})
+		return
+	}
+	if r.Header.Get("X-Vault-Token") != testVaultToken {
+		w.WriteHeader(http.StatusForbidden)
+		writeJSON(vaultError{Errors: []string{"permission denied"}})
+		return
+	}
+	switch r.URL.Path {
+	case "/v1/transit/keys/licence":
+		publicKey, err := v.publicKey()
+		if err != nil {
+			v.fail(w, err)
+			return
+		}
+		writeJSON(map[string]any{"data": map[string]any{
+			"type":           v.keyType,
+			"latest_version": 1,
+			"keys":           map[string]any{"1": map[string]string{"public_key": publicKey}},
+		}})
+	case "/v1/transit/sign/licence":
+		var request vaultSignRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		v.lock.Lock()
+		v.requests = append(v.requests, request)
+		v.lock.Unlock()
+		input, err := base64.StdEncoding.DecodeString(request.Input)
+		if err != nil {
+			v.fail(w, err)
+			return
+		}
+		var opts crypto.SignerOpts = crypto.Hash(0)
+		if request.Prehashed {
+			opts = crypto.SHA256
+		}
+		signature, err := v.signingKey.Sign(rand.Reader, input, opts)
+		if err != nil {
+			v.fail(w, err)
+			return
+		}
+		writeJSON(map[string]any{"data": map[string]string{
+			"signature": "vault:v1:" + base64.StdEncoding.EncodeToString(signature),
+		}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func startVault(t *testing.T, keyType string, key crypto.Signer) *fakeVault {
+	t.Helper()
+	vault := &fakeVault{t: t, keyType: keyType, key: key, signingKey: key}
+	server := httptest.NewServer(vault)
+	t.Cleanup(server.Close)
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("VAULT_ROLE_ID", "")
+	t.Setenv("VAULT_SECRET_ID", "")
+	return vault
+}
+
+func testKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]crypto.Signer{"ed25519": ed25519Key, "ecdsa-p256": ecdsaKey, "rsa-2048": rsaKey}
+}
+
+func TestVaultSignToken(t *testing.T) {
+	for keyType, key := range testKeys(t) {
+		t.Run(keyType, func(t *testing.T) {
+			vault := startVault(t, keyType, key)
+			t.Setenv("VAULT_TOKEN", testVaultToken)
+
+			signer, err := Open("vault:licence")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !key.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(signer.Public()) {
+				t.Fatal("vault signer returned a different public key")
+			}
+
+			message := []byte("licence")
+			digest, opts := message, crypto.SignerOpts(crypto.Hash(0))
+			if keyType != "ed25519" {
+				sum := sha256.Sum256(message)
+				digest, opts = sum[:], crypto.SHA256
+			}
+			signature, err := signer.Sign(rand.Reader, digest, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = verifyDigest(key.Public(), digest, signature, opts.HashFunc())
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+
+			request := vault.signRequests()[0]
+			if keyType == "ed25519" {
+				if request.Prehashed || request.HashAlgorithm != "" {
+					t.Fatalf("ed25519 request = %+v, want the message unhashed", request)
+				}
+			} else if !request.Prehashed || request.HashAlgorithm != "sha2-256" || request.SignatureAlgorithm != "pkcs1v15" {
+				t.Fatalf("request = %+v, want a prehashed sha2-256 pkcs1v15 request", request)
+			}
+		})
+	}
+}
+
+func TestVaultSignAppRole(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	startVault(t, "ed25519", key)
+
+	t.Setenv("VAULT_ROLE_ID", testVaultRoleId)
+	t.Setenv("VAULT_SECRET_ID", "wrong")
+	_, err = Open("vault:licence")
+	if err == nil || !strings.Contains(err.Error(), "invalid role or secret ID") {
+		t.Fatalf("login with a wrong secret ID: %v", err)
+	}
+
+	t.Setenv("VAULT_SECRET_ID", testVaultSecret)
+	signer, err := Open("vault:licence")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := signer.Sign(rand.Reader, []byte("licence"), crypto.Hash(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(key.Public().(ed25519.PublicKey), []byte("licence"), signature) {
+		t.Fatal("AppRole signature does not verify")
+	}
+}
+
+func TestVaultSignWrongKey(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vault := startVault(t, "ed25519", key)
+	vault.signingKey = other
+	t.Setenv("VAULT_TOKEN", testVaultToken)
+
+	signer, err := Open("vault:licence")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = signer.Sign(rand.Reader, []byte("licence"), crypto.Hash(0))
+	if err == nil {
+		t.Fatal("signature from another key accepted")
+	}
+}
+
+func TestDecodeVaultSignature(t *testing.T) {
+	signature, err := decodeVaultSignature("vault:v12:" + base64.StdEncoding.EncodeToString([]byte("signature")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(signature) != "signature" {
+		t.Fatalf("signature = %q", signature)
+	}
+
+	for _, invalid := range []string{"", "c2lnbmF0dXJl", "vault:c2lnbmF0dXJl", "vault:v1", "vault:v1:not base64!"} {
+		_, err := decodeVaultSignature(invalid)
+		if err == nil {
+			t.Errorf("decodeVaultSignature(%q) succeeded", invalid)
+		}
+	}
+}
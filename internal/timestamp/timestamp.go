@@ -0,0 +1,455 @@
+// Package timestamp obtains and verifies RFC 3161 time-stamp tokens, which
+// prove that a signature existed at a time vouched for by a trusted TSA.
+package timestamp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/fs"
+)
+
+const (
+	requestContentType  string = "application/timestamp-query"
+	responseContentType string = "application/timestamp-reply"
+)
+
+var (
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+)
+
+var hashAlgorithms = []struct {
+	oid  asn1.ObjectIdentifier
+	hash crypto.Hash
+}{
+	{oidSHA256, crypto.SHA256},
+	{oidSHA384, crypto.SHA384},
+	{oidSHA512, crypto.SHA512},
+}
+
+var pkiStatuses = map[int]string{
+	2: "rejection",
+	3: "waiting",
+	4: "revocation warning",
+	5: "revocation notification",
+}
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int `asn1:"optional"`
+	CertReq        bool     `asn1:"optional,default:false"`
+}
+
+type pkiStatusInfo struct {
+	Status int
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type rawContent struct {
+	Raw asn1.RawContent
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     rawContent   `asn1:"optional,tag:0"`
+	CRLs             rawContent   `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SignerIdentifier   asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttributes   rawContent `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time `asn1:"generalized"`
+	Accuracy       accuracy  `asn1:"optional"`
+	Ordering       bool      `asn1:"optional,default:false"`
+	Nonce          *big.Int  `asn1:"optional"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// ErrRevoked is returned when a signature was not timestamped before the
+// signing key was revoked.
+var ErrRevoked = errors.New("signing key was revoked")
+
+type VerifyOptions struct {
+	// Roots used to validate the TSA certificate. Defaults to the system pool.
+	Roots *x509.CertPool
+	// RevokedAt is when the signing key was revoked. When set, only
+	// signatures timestamped before it are accepted.
+	RevokedAt time.Time
+}
+
+func hashFor(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	for _, algorithm := range hashAlgorithms {
+		if algorithm.oid.Equal(oid) {
+			return algorithm.hash, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported hash algorithm '%s'", oid)
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// Request asks the TSA at url for a token over data and returns the DER
+// encoded token.
+func Request(url string, data []byte) ([]byte, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	request, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: digest(crypto.SHA256, data),
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Post(url, requestContentType, bytes.NewReader(request))
+	if err != nil {
+		return nil, fmt.Errorf("timestamp request failed: %w", err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timestamp response: %w", err)
+	}
+	if response.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("TSA returned %s", response.Status)
+	}
+	if contentType := response.Header.Get("Content-Type"); contentType != "" && contentType != responseContentType {
+		return nil, fmt.Errorf("TSA returned unexpected content type '%s'", contentType)
+	}
+
+	var resp timeStampResp
+	_, err = asn1.Unmarshal(body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp response: %w", err)
+	}
+	if resp.Status.Status > 1 {
+		status, ok := pkiStatuses[resp.Status.Status]
+		if !ok {
+			status = fmt.Sprintf("status %d", resp.Status.Status)
+		}
+		return nil, fmt.Errorf("TSA refused the request: %s", status)
+	}
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, errors.New("TSA response contains no token")
+	}
+
+	token := resp.TimeStampToken.FullBytes
+	info, _, err := parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return nil, errors.New("TSA response nonce does not match the request")
+	}
+	err = checkImprint(info, data)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func parseToken(token []byte) (tstInfo, signedData, error) {
+	var ci contentInfo
+	rest, err := asn1.Unmarshal(token, &ci)
+	if err != nil || len(rest) != 0 {
+		return tstInfo{}, signedData{}, fmt.Errorf("invalid timestamp token: %v", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return tstInfo{}, signedData{}, errors.New("timestamp token is not CMS signed data")
+	}
+	var sd signedData
+	_, err = asn1.Unmarshal(ci.Content.Bytes, &sd)
+	if err != nil {
+		return tstInfo{}, signedData{}, fmt.Errorf("invalid timestamp signed data: %w", err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return tstInfo{}, signedData{}, errors.New("timestamp token does not contain TSTInfo")
+	}
+	var info tstInfo
+	_, err = asn1.Unmarshal(sd.EncapContentInfo.EContent, &info)
+	if err != nil {
+		return tstInfo{}, signedData{}, fmt.Errorf("invalid TSTInfo: %w", err)
+	}
+	return info, sd, nil
+}
+
+func checkImprint(info tstInfo, data []byte) error {
+	hash, err := hashFor(info.MessageImprint.HashAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(digest(hash, data), info.MessageImprint.HashedMessage) != 1 {
+		return errors.New("timestamp token does not cover this signature")
+	}
+	return nil
+}
+
+func signatureAlgorithm(hash crypto.Hash, public crypto.PublicKey) (x509.SignatureAlgorithm, error) {
+	algorithms := map[crypto.Hash][2]x509.SignatureAlgorithm{
+		crypto.SHA256: {x509.SHA256WithRSA, x509.ECDSAWithSHA256},
+		crypto.SHA384: {x509.SHA384WithRSA, x509.ECDSAWithSHA384},
+		crypto.SHA512: {x509.SHA512WithRSA, x509.ECDSAWithSHA512},
+	}
+	switch public.(type) {
+	case *rsa.PublicKey:
+		return algorithms[hash][0], nil
+	case *ecdsa.PublicKey:
+		return algorithms[hash][1], nil
+	case ed25519.PublicKey:
+		return x509.PureEd25519, nil
+	default:
+		return 0, errors.New("unsupported TSA key type")
+	}
+}
+
+func findSigner(certificates []*x509.Certificate, identifier asn1.RawValue) (*x509.Certificate, error) {
+	if identifier.Class == asn1.ClassContextSpecific && identifier.Tag == 0 {
+		for _, certificate := range certificates {
+			if bytes.Equal(certificate.SubjectKeyId, identifier.Bytes) {
+				return certificate, nil
+			}
+		}
+		return nil, errors.New("TSA certificate not found in timestamp token")
+	}
+	var ias issuerAndSerialNumber
+	_, err := asn1.Unmarshal(identifier.FullBytes, &ias)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp signer identifier: %w", err)
+	}
+	for _, certificate := range certificates {
+		if bytes.Equal(certificate.RawIssuer, ias.Issuer.FullBytes) && certificate.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			return certificate, nil
+		}
+	}
+	return nil, errors.New("TSA certificate not found in timestamp token")
+}
+
+func verifySignedAttributes(raw []byte, content []byte, hash crypto.Hash) error {
+	if len(raw) == 0 {
+		return errors.New("timestamp token has no signed attributes")
+	}
+	var attributes []attribute
+	_, err := asn1.UnmarshalWithParams(raw, &attributes, "set,tag:0")
+	if err != nil {
+		return fmt.Errorf("invalid timestamp signed attributes: %w", err)
+	}
+	var contentTypeOk, digestOk bool
+	for _, attr := range attributes {
+		switch {
+		case attr.Type.Equal(oidContentType):
+			var contentType asn1.ObjectIdentifier
+			_, err = asn1.Unmarshal(attr.Values.Bytes, &contentType)
+			contentTypeOk = err == nil && contentType.Equal(oidTSTInfo)
+		case attr.Type.Equal(oidMessageDigest):
+			var messageDigest []byte
+			_, err = asn1.Unmarshal(attr.Values.Bytes, &messageDigest)
+			digestOk = err == nil && subtle.ConstantTimeCompare(messageDigest, digest(hash, content)) == 1
+		}
+	}
+	if !contentTypeOk {
+		return errors.New("timestamp token content type attribute is invalid")
+	}
+	if !digestOk {
+		return errors.New("timestamp token message digest does not match its content")
+	}
+	return nil
+}
+
+// Verify checks that token is a valid timestamp over data issued by a TSA
+// trusted by roots and returns the time it vouches for.
+func Verify(token, data []byte, roots *x509.CertPool) (time.Time, error) {
+	info, sd, err := parseToken(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	err = checkImprint(info, data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(sd.SignerInfos) != 1 {
+		return time.Time{}, errors.New("timestamp token must have exactly one signer")
+	}
+	si := sd.SignerInfos[0]
+
+	var certificates []*x509.Certificate
+	if len(sd.Certificates.Raw) != 0 {
+		var set asn1.RawValue
+		_, err = asn1.Unmarshal(sd.Certificates.Raw, &set)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp certificates: %w", err)
+		}
+		certificates, err = x509.ParseCertificates(set.Bytes)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp certificates: %w", err)
+		}
+	}
+	certificate, err := findSigner(certificates, si.SignerIdentifier)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	hash, err := hashFor(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return time.Time{}, err
+	}
+	err = verifySignedAttributes(si.SignedAttributes.Raw, sd.EncapContentInfo.EContent, hash)
+	if err != nil {
+		return time.Time{}, err
+	}
+	algorithm, err := signatureAlgorithm(hash, certificate.PublicKey)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// The signature covers the DER encoding of the attributes as a SET, not
+	// the implicitly tagged form they are stored in.
+	signed := bytes.Clone(si.SignedAttributes.Raw)
+	signed[0] = asn1.TagSet | 0x20
+	err = certificate.CheckSignature(algorithm, signed, si.Signature)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp signature: %w", err)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certificates {
+		if c != certificate {
+			intermediates.AddCert(c)
+		}
+	}
+	_, err = certificate.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   info.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("untrusted TSA certificate: %w", err)
+	}
+	return info.GenTime, nil
+}
+
+// Check verifies an optional token over signature and applies the key
+// revocation time in options. It returns the zero time when token is nil.
+func Check(token, signature []byte, options VerifyOptions) (time.Time, error) {
+	if token == nil {
+		if !options.RevokedAt.IsZero() {
+			return time.Time{}, fmt.Errorf("%w and the signature has no trusted timestamp", ErrRevoked)
+		}
+		return time.Time{}, nil
+	}
+	signedAt, err := Verify(token, signature, options.Roots)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !options.RevokedAt.IsZero() && !signedAt.Before(options.RevokedAt) {
+		return time.Time{}, fmt.Errorf("%w: signature was timestamped at %s, after the revocation", ErrRevoked,
+			signedAt.UTC().Format(time.RFC3339))
+	}
+	return signedAt, nil
+}
+
+// LoadRoots reads a PEM bundle of trusted TSA root certificates.
+func LoadRoots(path string) (*x509.CertPool, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in '%s'", path)
+	}
+	return roots, nil
+}
+
+// ParseTime accepts either a date (yyyy-mm-dd, UTC midnight) or an RFC 3339 timestamp.
+func ParseTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.ParseInLocation(time.DateOnly, value, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s', expected yyyy-mm-dd or RFC 3339", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,306 @@
+package timestamp
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidTestPolicy      = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+)
+
+// The token structures are marshalled with raw values because the parser's
+// types only describe how tokens are read.
+type testSignerInfo struct {
+	Version            int
+	SignerIdentifier   asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttributes   asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type testSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      []testSignerInfo `asn1:"set"`
+}
+
+type testAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// testTSA is an RFC 3161 time-stamping authority with its own root CA.
+type testTSA struct {
+	t           *testing.T
+	roots       *x509.CertPool
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	// status is the PKIStatus returned, 0 (granted) by default.
+	status int
+	// badNonce makes the TSA answer with a nonce other than the requested one.
+	badNonce bool
+}
+
+func newTestTSA(t *testing.T) *testTSA {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test TSA Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test TSA"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}, ca, key.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	return &testTSA{t: t, roots: roots, certificate: certificate, key: key}
+}
+
+func mustMarshal(t *testing.T, value any, params string) []byte {
+	t.Helper()
+	der, err := asn1.MarshalWithParams(value, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func attributeOf(t *testing.T, oid asn1.ObjectIdentifier, value any) testAttribute {
+	return testAttribute{Type: oid, Values: asn1.RawValue{
+		Tag: asn1.TagSet, IsCompound: true, Bytes: mustMarshal(t, value, ""),
+	}}
+}
+
+// token returns a CMS SignedData time-stamp token for info.
+func (tsa *testTSA) token(info tstInfo) []byte {
+	t := tsa.t
+	content := mustMarshal(t, info, "")
+	contentDigest := sha256.Sum256(content)
+	attributes := mustMarshal(t, []testAttribute{
+		attributeOf(t, oidContentType, oidTSTInfo),
+		attributeOf(t, oidMessageDigest, contentDigest[:]),
+	}, "set")
+	attributesDigest := sha256.Sum256(attributes)
+	signature, err := ecdsa.SignASN1(rand.Reader, tsa.key, attributesDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Signed attributes are signed as a SET but stored implicitly tagged [0].
+	attributes[0] = asn1.ClassContextSpecific<<6 | 0x20
+
+	sha256Algorithm := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	signedData := mustMarshal(t, testSignedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Algorithm},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidTSTInfo, EContent: content},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: tsa.certificate.Raw},
+		SignerInfos: []testSignerInfo{{
+			Version: 1,
+			SignerIdentifier: asn1.RawValue{FullBytes: mustMarshal(t, issuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: tsa.certificate.RawIssuer},
+				SerialNumber: tsa.certificate.SerialNumber,
+			}, "")},
+			DigestAlgorithm:    sha256Algorithm,
+			SignedAttributes:   asn1.RawValue{FullBytes: attributes},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			Signature:          signature,
+		}},
+	}, "")
+	return mustMarshal(t, contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData},
+	}, "")
+}
+
+func (tsa *testTSA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		tsa.t.Fatal(err)
+	}
+	var request timeStampReq
+	_, err = asn1.Unmarshal(body, &request)
+	if err != nil || r.Header.Get("Content-Type") != requestContentType {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response := timeStampResp{Status: pkiStatusInfo{Status: tsa.status}}
+	if tsa.status == 0 {
+		nonce := request.Nonce
+		if tsa.badNonce {
+			nonce = new(big.Int).Add(nonce, big.NewInt(1))
+		}
+		response.TimeStampToken = asn1.RawValue{FullBytes: tsa.token(tstInfo{
+			Version:        1,
+			Policy:         oidTestPolicy,
+			MessageImprint: request.MessageImprint,
+			SerialNumber:   big.NewInt(1),
+			GenTime:        time.Now().UTC().Truncate(time.Second),
+			Nonce:          nonce,
+		})}
+	}
+	w.Header().Set("Content-Type", responseContentType)
+	w.Write(mustMarshal(tsa.t, response, ""))
+}
+
+func startTSA(t *testing.T) (*testTSA, string) {
+	tsa := newTestTSA(t)
+	server := httptest.NewServer(tsa)
+	t.Cleanup(server.Close)
+	return tsa, server.URL
+}
+
+func TestRequestVerify(t *testing.T) {
+	tsa, url := startTSA(t)
+	data := []byte("signature")
+
+	before := time.Now().Add(-time.Second)
+	token, err := Request(url, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedAt, err := Verify(token, data, tsa.roots)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if signedAt.Before(before.Truncate(time.Second)) || signedAt.After(time.Now()) {
+		t.Fatalf("token time %s is not the time of the request", signedAt)
+	}
+
+	_, err = Verify(token, []byte("other signature"), tsa.roots)
+	if err == nil {
+		t.Fatal("token verified over other data")
+	}
+	_, err = Verify(token, data, newTestTSA(t).roots)
+	if err == nil {
+		t.Fatal("token verified with an untrusted TSA")
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	tsa, url := startTSA(t)
+	data := []byte("signature")
+	token, err := Request(url, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The ECDSA signature is the last field of the token.
+	signature := bytes.Clone(token)
+	signature[len(signature)-1] ^= 1
+	_, err = Verify(signature, data, tsa.roots)
+	if err == nil {
+		t.Fatal("token with a modified signature verified")
+	}
+
+	info, _, err := parseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genTime := []byte(info.GenTime.Format("20060102150405Z"))
+	index := bytes.Index(token, genTime)
+	if index < 0 {
+		t.Fatal("generation time not found in token")
+	}
+	backdated := bytes.Clone(token)
+	backdated[index+3] ^= 1 // change the year
+	_, err = Verify(backdated, data, tsa.roots)
+	if err == nil {
+		t.Fatal("token with a modified time verified")
+	}
+}
+
+func TestRequestRejected(t *testing.T) {
+	tsa, url := startTSA(t)
+
+	tsa.status = 2
+	_, err := Request(url, []byte("signature"))
+	if err == nil {
+		t.Fatal("rejected request returned a token")
+	}
+
+	tsa.status = 0
+	tsa.badNonce = true
+	_, err = Request(url, []byte("signature"))
+	if err == nil {
+		t.Fatal("token with a mismatched nonce accepted")
+	}
+}
+
+func TestCheckRevokedAt(t *testing.T) {
+	tsa, url := startTSA(t)
+	data := []byte("signature")
+	token, err := Request(url, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Check(token, data, VerifyOptions{Roots: tsa.roots, RevokedAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("token before revocation: %v", err)
+	}
+	_, err = Check(token, data, VerifyOptions{Roots: tsa.roots, RevokedAt: time.Now().Add(-time.Hour)})
+	if !errors.Is(err, ErrRevoked) {
+		t.Fatalf("token after revocation: %v, want ErrRevoked", err)
+	}
+	_, err = Check(nil, data, VerifyOptions{RevokedAt: time.Now()})
+	if !errors.Is(err, ErrRevoked) {
+		t.Fatalf("missing token after revocation: %v, want ErrRevoked", err)
+	}
+	_, err = Check(nil, data, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("missing token without revocation: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package translog
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/bits"
+)
+
+// Hashes follow RFC 6962 section 2.1: leaves and interior nodes are domain
+// separated by a one byte prefix so a leaf can never be passed off as a node.
+const (
+	leafPrefix byte = 0
+	nodePrefix byte = 1
+)
+
+func leafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// split returns the largest power of two smaller than n.
+func split(n int) int {
+	return 1 << (bits.Len(uint(n-1)) - 1)
+}
+
+func rootHash(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		empty := sha256.Sum256(nil)
+		return empty[:]
+	case 1:
+		return leaves[0]
+	}
+	k := split(len(leaves))
+	return nodeHash(rootHash(leaves[:k]), rootHash(leaves[k:]))
+}
+
+func auditPath(index int, leaves [][]byte) [][]byte {
+	if len(leaves) <= 1 {
+		return nil
+	}
+	k := split(len(leaves))
+	if index < k {
+		return append(auditPath(index, leaves[:k]), rootHash(leaves[k:]))
+	}
+	return append(auditPath(index-k, leaves[k:]), rootHash(leaves[:k]))
+}
+
+// rootFromPath recomputes the tree root from a leaf hash and its audit path
+// as described in RFC 9162 section 2.1.3.2.
+func rootFromPath(leaf []byte, index, size uint64, path [][]byte) ([]byte, error) {
+	if index >= size {
+		return nil, errors.New("leaf index is outside the tree")
+	}
+	fn, sn := index, size-1
+	r := leaf
+	for _, p := range path {
+		if sn == 0 {
+			return nil, errors.New("audit path is too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return nil, errors.New("audit path is too short")
+	}
+	return r, nil
+}
@@ -0,0 +1,295 @@
+// Package translog keeps an append-only Merkle tree log of issued licences
+// in the style of RFC 6962 certificate transparency. Every append produces a
+// tree head signed by the issuer so auditors can pin it and later demand
+// inclusion proofs for licences they encounter.
+package translog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eslam-allam/file-signer/internal/key"
+	"github.com/eslam-allam/file-signer/internal/sign"
+)
+
+const (
+	leavesFileName        string = "leaves"
+	treeHeadsFileName     string = "tree-heads.jsonl"
+	TREE_HEAD_FILE_NAME   string = "tree-head.json"
+	treeHeadSignatureType byte   = 1
+)
+
+type TreeHead struct {
+	TreeSize  uint64 `json:"tree_size"`
+	Timestamp int64  `json:"timestamp"`
+	RootHash  []byte `json:"root_hash"`
+	KeyId     string `json:"key_id"`
+	Signature []byte `json:"signature"`
+}
+
+type InclusionProof struct {
+	LeafIndex uint64   `json:"leaf_index"`
+	TreeSize  uint64   `json:"tree_size"`
+	AuditPath [][]byte `json:"audit_path"`
+	TreeHead  TreeHead `json:"tree_head"`
+}
+
+type Log struct {
+	dir string
+}
+
+// signedData is the RFC 6962 TreeHeadSignature structure: version,
+// signature type, timestamp in milliseconds, tree size and root hash.
+func (h TreeHead) signedData() []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(0)
+	buf.WriteByte(treeHeadSignatureType)
+	binary.Write(&buf, binary.BigEndian, uint64(h.Timestamp))
+	binary.Write(&buf, binary.BigEndian, h.TreeSize)
+	buf.Write(h.RootHash)
+	return buf.Bytes()
+}
+
+// Open opens the log stored in dir, creating it when missing.
+func Open(dir string) (*Log, error) {
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log directory '%s': %w", dir, err)
+	}
+	return &Log{dir: dir}, nil
+}
+
+func (l *Log) leaves() ([][]byte, error) {
+	data, err := os.ReadFile(filepath.Join(l.dir, leavesFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%sha256.Size != 0 {
+		return nil, fmt.Errorf("log '%s' is corrupted: truncated leaf", l.dir)
+	}
+	leaves := make([][]byte, 0, len(data)/sha256.Size)
+	for i := 0; i < len(data); i += sha256.Size {
+		leaves = append(leaves, data[i:i+sha256.Size])
+	}
+	return leaves, nil
+}
+
+// writeFileAtomic replaces the file at path with data so that readers and a
+// crash part way through see either the old or the new contents.
+func writeFileAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// treeHeads reads the tree head history, oldest first.
+func (l *Log) treeHeads() ([]TreeHead, error) {
+	file, err := os.Open(filepath.Join(l.dir, treeHeadsFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var heads []TreeHead
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var head TreeHead
+		err = json.Unmarshal(scanner.Bytes(), &head)
+		if err != nil {
+			return nil, fmt.Errorf("log '%s' is corrupted: %w", l.dir, err)
+		}
+		heads = append(heads, head)
+	}
+	return heads, scanner.Err()
+}
+
+// Append records entry in the log and returns the new tree head signed by signer.
+//
+// Each file is replaced atomically, and the tree head history is written
+// before the leaves. An interrupted append can therefore only leave a head
+// for a tree larger than the leaves, which is never used for proofs and is
+// dropped by the next append, or a stale tree-head.json.
+func (l *Log) Append(signer crypto.Signer, entry []byte) (TreeHead, error) {
+	leaves, err := l.leaves()
+	if err != nil {
+		return TreeHead{}, err
+	}
+	leaf := leafHash(entry)
+	for _, existing := range leaves {
+		if bytes.Equal(existing, leaf) {
+			return TreeHead{}, errors.New("entry is already recorded in the log")
+		}
+	}
+	leaves = append(leaves, leaf)
+
+	keyId, err := key.Fingerprint(signer.Public())
+	if err != nil {
+		return TreeHead{}, err
+	}
+	head := TreeHead{
+		TreeSize:  uint64(len(leaves)),
+		Timestamp: time.Now().UnixMilli(),
+		RootHash:  rootHash(leaves),
+		KeyId:     keyId,
+	}
+	head.Signature, err = sign.SignMessage(signer, head.signedData())
+	if err != nil {
+		return TreeHead{}, err
+	}
+
+	heads, err := l.treeHeads()
+	if err != nil {
+		return TreeHead{}, err
+	}
+	var history bytes.Buffer
+	kept := heads[:0]
+	for _, existing := range heads {
+		// Heads at or beyond the new size were left by an interrupted append.
+		if existing.TreeSize < head.TreeSize {
+			kept = append(kept, existing)
+		}
+	}
+	for _, existing := range append(kept, head) {
+		line, err := json.Marshal(existing)
+		if err != nil {
+			return TreeHead{}, err
+		}
+		history.Write(append(line, '\n'))
+	}
+	latest, err := json.MarshalIndent(head, "", "  ")
+	if err != nil {
+		return TreeHead{}, err
+	}
+
+	err = writeFileAtomic(filepath.Join(l.dir, treeHeadsFileName), history.Bytes())
+	if err != nil {
+		return TreeHead{}, err
+	}
+	err = writeFileAtomic(filepath.Join(l.dir, leavesFileName), bytes.Join(leaves, nil))
+	if err != nil {
+		return TreeHead{}, err
+	}
+	return head, writeFileAtomic(filepath.Join(l.dir, TREE_HEAD_FILE_NAME), latest)
+}
+
+func (l *Log) treeHead(size uint64) (TreeHead, error) {
+	heads, err := l.treeHeads()
+	if err != nil {
+		return TreeHead{}, err
+	}
+	for _, head := range heads {
+		if head.TreeSize == size {
+			return head, nil
+		}
+	}
+	return TreeHead{}, fmt.Errorf("log has no tree head of size %d", size)
+}
+
+// Prove builds an inclusion proof for entry against the tree head of
+// treeSize, or the latest tree head when treeSize is 0.
+func (l *Log) Prove(entry []byte, treeSize uint64) (InclusionProof, error) {
+	leaves, err := l.leaves()
+	if err != nil {
+		return InclusionProof{}, err
+	}
+	if treeSize == 0 {
+		treeSize = uint64(len(leaves))
+	}
+	if treeSize > uint64(len(leaves)) {
+		return InclusionProof{}, fmt.Errorf("log only has %d entries", len(leaves))
+	}
+	leaves = leaves[:treeSize]
+
+	leaf := leafHash(entry)
+	index := -1
+	for i, existing := range leaves {
+		if bytes.Equal(existing, leaf) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return InclusionProof{}, fmt.Errorf("entry is not recorded in the first %d log entries", treeSize)
+	}
+	head, err := l.treeHead(treeSize)
+	if err != nil {
+		return InclusionProof{}, err
+	}
+	if !bytes.Equal(head.RootHash, rootHash(leaves)) {
+		return InclusionProof{}, fmt.Errorf("log '%s' is corrupted: tree head of size %d does not match its entries", l.dir, treeSize)
+	}
+	return InclusionProof{
+		LeafIndex: uint64(index),
+		TreeSize:  treeSize,
+		AuditPath: auditPath(index, leaves),
+		TreeHead:  head,
+	}, nil
+}
+
+// VerifyTreeHead checks the signature on head using the log's public key.
+func VerifyTreeHead(head TreeHead, publicKey crypto.PublicKey) error {
+	keyId, err := key.Fingerprint(publicKey)
+	if err != nil {
+		return err
+	}
+	if head.KeyId != keyId {
+		return fmt.Errorf("tree head was signed by key '%s' but log key is '%s'", head.KeyId, keyId)
+	}
+	err = sign.VerifySignature(head.Signature, head.signedData(), publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid tree head signature: %w", err)
+	}
+	return nil
+}
+
+// VerifyInclusion checks that proof shows entry is included in the tree
+// described by the trusted head, which must be signed by publicKey.
+func VerifyInclusion(entry []byte, proof InclusionProof, head TreeHead, publicKey crypto.PublicKey) error {
+	err := VerifyTreeHead(head, publicKey)
+	if err != nil {
+		return err
+	}
+	if proof.TreeSize != head.TreeSize {
+		return fmt.Errorf("proof is for a tree of size %d but the trusted tree head has size %d", proof.TreeSize, head.TreeSize)
+	}
+	root, err := rootFromPath(leafHash(entry), proof.LeafIndex, proof.TreeSize, proof.AuditPath)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(root, head.RootHash) {
+		return errors.New("inclusion proof does not match the trusted tree head")
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package translog
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendProve(t *testing.T) {
+	_, signer, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries [][]byte
+	for i := 0; i < 5; i++ {
+		entry := []byte(fmt.Sprintf("licence %d", i))
+		head, err := l.Append(signer, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if head.TreeSize != uint64(i+1) {
+			t.Fatalf("tree size = %d, want %d", head.TreeSize, i+1)
+		}
+		entries = append(entries, entry)
+	}
+	_, err = l.Append(signer, entries[0])
+	if err == nil {
+		t.Fatal("duplicate entry appended")
+	}
+
+	latest, err := os.ReadFile(filepath.Join(l.dir, TREE_HEAD_FILE_NAME))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var head TreeHead
+	err = json.Unmarshal(latest, &head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		proof, err := l.Prove(entry, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = VerifyInclusion(entry, proof, head, signer.Public())
+		if err != nil {
+			t.Fatalf("verify %q: %v", entry, err)
+		}
+	}
+	err = VerifyInclusion([]byte("other"), InclusionProof{TreeSize: head.TreeSize}, head, signer.Public())
+	if err == nil {
+		t.Fatal("unlogged entry verified")
+	}
+}
+
+func TestAppendInterrupted(t *testing.T) {
+	_, signer, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = l.Append(signer, []byte("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An append interrupted after writing the tree head history leaves a
+	// head for a tree the leaves do not contain.
+	orphan, err := (&Log{dir: t.TempDir()}).Append(signer, []byte("orphan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orphan.TreeSize = 2
+	line, err := json.Marshal(orphan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := filepath.Join(l.dir, treeHeadsFileName)
+	data, err := os.ReadFile(history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(history, append(data, append(line, '\n')...), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = l.Prove([]byte("first"), 0)
+	if err != nil {
+		t.Fatalf("prove after an interrupted append: %v", err)
+	}
+	head, err := l.Append(signer, []byte("second"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := l.Prove([]byte("second"), 2)
+	if err != nil {
+		t.Fatalf("prove after recovering: %v", err)
+	}
+	err = VerifyInclusion([]byte("second"), proof, head, signer.Public())
+	if err != nil {
+		t.Fatalf("verify after recovering: %v", err)
+	}
+	heads, err := l.treeHeads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heads) != 2 {
+		t.Fatalf("history has %d heads, want the orphan dropped", len(heads))
+	}
+}